@@ -1,69 +1,104 @@
 package spark
 
 import (
-	"bufio"
-	"fmt"
-	"math"
 	"os"
-	"strconv"
-	"strings"
 	"unicode"
 )
 
 func ValidateArgs(args []string, stdin *os.File) ([]int, error) {
+	data, _, err := ParseArgs(args, stdin, &Config{})
+	return data, err
+}
+
+// ParseArgs parses the numbers from args, or from stdin when there are no args, with the parsing
+// options of config. It also returns how many invalid tokens were skipped.
+func ParseArgs(args []string, stdin *os.File, config *Config) ([]int, int, error) {
 	hasArgs := len(args) > 0
 
-	stdinStat, _ := stdin.Stat()
-	hasStdinData := (stdinStat.Mode() & os.ModeCharDevice) == 0
-
-	var source []string
-	if hasArgs {
-		source = args
-	} else if hasStdinData {
-		scanner := bufio.NewScanner(stdin)
-		for scanner.Scan() {
-			source = append(source, scanner.Text())
-		}
+	if !hasArgs && isPiped(stdin) {
+		data, skipped, err := parseReader(stdin, config)
+		return data, skipped, setSource(err, "<stdin>")
 	}
 
-	return parseSource(source)
+	data, skipped, err := parseSource(args, config)
+	return data, skipped, setSource(err, "<args>")
 }
 
-func parseSource(source []string) ([]int, error) {
-	var flattened []string
-	for _, s := range source {
-		flattened = append(flattened, strings.FieldsFunc(s, isSeparator)...)
+func parseSource(source []string, config *Config) ([]int, int, error) {
+	re, err := config.matcher()
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if len(flattened) < 1 {
-		return nil, fmt.Errorf("no numeric data provided - specify numbers as arguments or pipe data via stdin")
-	}
-
-	data := make([]int, 0, len(flattened))
-	for _, n := range flattened {
-		f, err := strconv.ParseFloat(n, 64)
+	var data []int
+	skipped := 0
+	for i, s := range source {
+		values, n, err := parseTokens(lineTokens(s, re), i+1, config)
+		skipped += n
 		if err != nil {
-			return nil, fmt.Errorf("invalid number: %s", n)
+			return nil, skipped, err
 		}
 
-		// check bounds
-		if math.IsInf(f, 0) {
-			return nil, fmt.Errorf("infinite numbers not supported: %s", n)
-		}
-		if math.IsNaN(f) {
-			return nil, fmt.Errorf("NaN (not a number) not supported: %s", n)
-		}
-		if f > math.MaxInt64 {
-			return nil, fmt.Errorf("number is too large: %s", n)
+		data = append(data, values...)
+	}
+
+	if len(data) < 1 {
+		return nil, skipped, ErrNoData
+	}
+
+	return data, skipped, nil
+}
+
+// parseTokens parses the tokens of a line, skipping the invalid ones if config says so
+func parseTokens(tokens []field, line int, config *Config) ([]int, int, error) {
+	data := make([]int, 0, len(tokens))
+	skipped := 0
+	for _, f := range tokens {
+		n, err := parseNumber(f.token)
+		if err != nil && config.SkipInvalid {
+			skipped++
+			continue
 		}
-		if f < math.MinInt64 {
-			return nil, fmt.Errorf("number is too short: %s", n)
+		if err != nil {
+			return nil, skipped, &ParseError{Line: line, Column: f.column, Token: f.token, Err: err}
 		}
 
-		data = append(data, int(f))
+		data = append(data, n)
 	}
+	return data, skipped, nil
+}
+
+func isPiped(stdin *os.File) bool {
+	stdinStat, _ := stdin.Stat()
+	return (stdinStat.Mode() & os.ModeCharDevice) == 0
+}
 
-	return data, nil
+type field struct {
+	token  string
+	column int
+}
+
+// fields splits s around separators like strings.FieldsFunc, keeping the column of every token
+func fields(s string) []field {
+	var result []field
+	start, startColumn, column := -1, 0, 0
+	for i, r := range s {
+		column++
+		if !isSeparator(r) {
+			if start < 0 {
+				start, startColumn = i, column
+			}
+			continue
+		}
+		if start >= 0 {
+			result = append(result, field{token: s[start:i], column: startColumn})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		result = append(result, field{token: s[start:], column: startColumn})
+	}
+	return result
 }
 
 func isSeparator(r rune) bool {
@@ -0,0 +1,134 @@
+package spark
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	StatusOk = iota
+	StatusWarning
+	StatusCritical
+	StatusUnknown
+)
+
+var (
+	StatusNames = map[int]string{
+		StatusOk:       "OK",
+		StatusWarning:  "WARNING",
+		StatusCritical: "CRITICAL",
+		StatusUnknown:  "UNKNOWN",
+	}
+)
+
+func Check(data []int, aggregate string, config *Config) (int, string, error) {
+	transformed, err := config.transform(data)
+	if err != nil {
+		return StatusUnknown, "", err
+	}
+	if len(transformed) == 0 && len(data) > 0 {
+		return StatusUnknown, "", ErrNoValuesLeft
+	}
+	data = transformed
+
+	value, err := Aggregate(data, aggregate)
+	if err != nil {
+		return StatusUnknown, "", err
+	}
+
+	// plugin output is read by monitoring systems, so escape sequences would only get in the way
+	plain := *config
+	plain.ColorMode = ColorNever
+	plain.Transforms = nil
+
+	sparks, err := Spark(data, &plain)
+	if err != nil {
+		return StatusUnknown, "", err
+	}
+
+	status := StatusOk
+	switch getLevel(value, config) {
+	case levelWarn:
+		status = StatusWarning
+	case levelCrit:
+		status = StatusCritical
+	}
+
+	perfData := []string{
+		formatFloat(value),
+		formatThreshold(config.Warn),
+		formatThreshold(config.Crit),
+		strconv.Itoa(slices.Min(data)),
+		strconv.Itoa(slices.Max(data)),
+	}
+
+	line := fmt.Sprintf("%s - %s | %s=%s", StatusNames[status], sparks, aggregate, strings.Join(perfData, ";"))
+
+	return status, line, nil
+}
+
+func Aggregate(data []int, aggregate string) (float64, error) {
+	if len(data) == 0 {
+		return 0, fmt.Errorf("no data to aggregate")
+	}
+
+	switch aggregate {
+	case "first":
+		return float64(data[0]), nil
+	case "last":
+		return float64(data[len(data)-1]), nil
+	case "min":
+		return float64(slices.Min(data)), nil
+	case "max":
+		return float64(slices.Max(data)), nil
+	case "avg":
+		_, _, _, average, err := getStats(data)
+		return average, err
+	case "sum":
+		_, _, sum, _, err := getStats(data)
+		return float64(sum), err
+	case "count":
+		return float64(len(data)), nil
+	}
+
+	if strings.HasPrefix(aggregate, "p") {
+		p, err := strconv.ParseFloat(aggregate[1:], 64)
+		if err == nil && p > 0 && p <= 100 {
+			return percentile(data, p), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid aggregate: %s", aggregate)
+}
+
+// ValidateAggregate checks that aggregate is one of the aggregates supported by Aggregate
+func ValidateAggregate(aggregate string) error {
+	_, err := Aggregate([]int{0}, aggregate)
+	return err
+}
+
+// percentile uses the nearest-rank method, so the result is always one of the data points
+func percentile(data []int, p float64) float64 {
+	sorted := slices.Clone(data)
+	slices.Sort(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1])
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatThreshold(threshold *int) string {
+	if threshold == nil {
+		return ""
+	}
+	return strconv.Itoa(*threshold)
+}
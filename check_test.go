@@ -0,0 +1,146 @@
+package spark
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []int
+		aggregate      string
+		warn           *int
+		crit           *int
+		thresholdMode  string
+		expectedStatus int
+		expectedLine   string
+		expectError    bool
+		errorMsg       string
+	}{
+		{
+			name:           "last value ok",
+			args:           []int{95, 40, 12},
+			aggregate:      "last",
+			warn:           intPtr(70),
+			crit:           intPtr(90),
+			expectedStatus: StatusOk,
+			expectedLine:   "OK - █▃▁ | last=12;70;90;12;95",
+		},
+		{
+			name:           "last value warning",
+			args:           []int{12, 40, 75},
+			aggregate:      "last",
+			warn:           intPtr(70),
+			crit:           intPtr(90),
+			expectedStatus: StatusWarning,
+			expectedLine:   "WARNING - ▁▄█ | last=75;70;90;12;75",
+		},
+		{
+			name:           "max value critical",
+			args:           []int{12, 95, 40},
+			aggregate:      "max",
+			warn:           intPtr(70),
+			crit:           intPtr(90),
+			expectedStatus: StatusCritical,
+			expectedLine:   "CRITICAL - ▁█▃ | max=95;70;90;12;95",
+		},
+		{
+			name:           "average with decimals",
+			args:           []int{1, 2},
+			aggregate:      "avg",
+			crit:           intPtr(2),
+			expectedStatus: StatusOk,
+			expectedLine:   "OK - ▁█ | avg=1.5;;2;1;2",
+		},
+		{
+			name:           "percentile",
+			args:           []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			aggregate:      "p90",
+			warn:           intPtr(9),
+			expectedStatus: StatusWarning,
+			expectedLine:   "WARNING - ▁▁▂▃▄▄▅▆▇█ | p90=9;9;;1;10",
+		},
+		{
+			name:           "below thresholds",
+			args:           []int{80, 40, 15},
+			aggregate:      "last",
+			warn:           intPtr(20),
+			crit:           intPtr(10),
+			thresholdMode:  "below",
+			expectedStatus: StatusWarning,
+			expectedLine:   "WARNING - █▃▁ | last=15;20;10;15;80",
+		},
+		{
+			name:           "invalid aggregate",
+			args:           []int{1, 2},
+			aggregate:      "median",
+			expectedStatus: StatusUnknown,
+			expectError:    true,
+			errorMsg:       "invalid aggregate: median",
+		},
+		{
+			name:           "invalid percentile",
+			args:           []int{1, 2},
+			aggregate:      "p101",
+			expectedStatus: StatusUnknown,
+			expectError:    true,
+			errorMsg:       "invalid aggregate: p101",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				FgColor:       "red",
+				Warn:          tt.warn,
+				Crit:          tt.crit,
+				ThresholdMode: tt.thresholdMode,
+			}
+			status, line, err := Check(tt.args, tt.aggregate, config)
+
+			if status != tt.expectedStatus {
+				t.Errorf("got status %d, want %d", status, tt.expectedStatus)
+			}
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if tt.errorMsg != "" && err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if line != tt.expectedLine {
+				t.Errorf("got '%s', want '%s'", line, tt.expectedLine)
+			}
+		})
+	}
+}
+
+func TestCheckTransforms(t *testing.T) {
+	config := &Config{Crit: intPtr(5), Transforms: []string{"diff"}}
+	status, line, err := Check([]int{1, 2, 10}, "last", config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != StatusCritical || line != "CRITICAL - ▁█ | last=8;;5;1;8" {
+		t.Errorf("got status %d and line '%s'", status, line)
+	}
+}
+
+func TestCheckNoValuesLeft(t *testing.T) {
+	config := &Config{Transforms: []string{"diff"}}
+	status, _, err := Check([]int{5}, "last", config)
+	if status != StatusUnknown || !errors.Is(err, ErrNoValuesLeft) {
+		t.Errorf("got status %d and error %v, want %d and %v", status, err, StatusUnknown, ErrNoValuesLeft)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	spark "gospark"
+	"os"
+)
+
+func newCheckCmd(config *spark.Config, warn, crit *int) *cobra.Command {
+	var aggregate string
+
+	checkCmd := &cobra.Command{
+		Use:                   "check [flags]... value...",
+		DisableFlagsInUseLine: true,
+		Short:                 "Check values against thresholds like a monitoring plugin",
+		Long: `Check numeric data against the warning and critical thresholds, print a monitoring plugin
+status line with the sparkline and performance data, and exit with the standard plugin codes:
+0 (OK), 1 (WARNING), 2 (CRITICAL) or 3 (UNKNOWN).
+
+The thresholds are compared against an aggregate of the data: last (default), min, max, avg
+or a percentile such as p95.`,
+		Example: `  spark check --warn 70 --crit 90 -- 12 40 75          => WARNING - ▁▄█ | last=75;70;90;12;75
+ spark check --crit 90 --aggregate p95 -- 12 40 75   => OK - ▁▄█ | p95=75;;90;12;75`,
+		Run: func(cmd *cobra.Command, args []string) {
+			applyThresholds(cmd, config, warn, crit)
+
+			status, line, err := check(args, aggregate, config)
+			if err != nil {
+				fmt.Printf("%s - %v\n", spark.StatusNames[spark.StatusUnknown], err)
+				os.Exit(spark.StatusUnknown)
+			}
+
+			fmt.Println(line)
+			os.Exit(status)
+		},
+	}
+
+	checkCmd.Flags().StringVarP(&aggregate, "aggregate", "a", "last", "value compared against the thresholds (last, min, max, avg or a percentile such as p95)")
+
+	return checkCmd
+}
+
+func check(args []string, aggregate string, config *spark.Config) (int, string, error) {
+	if err := config.Validate(); err != nil {
+		return spark.StatusUnknown, "", err
+	}
+
+	data, err := parseArgs(args, config)
+	if err != nil {
+		return spark.StatusUnknown, "", err
+	}
+
+	return spark.Check(data, aggregate, config)
+}
+
+// checkFailed reports the errors of the check command that happen before it runs, such as unknown
+// flags or invalid settings, as the UNKNOWN plugin status: monitoring systems read 1 as WARNING.
+func checkFailed(cmd *cobra.Command, err error) (int, bool) {
+	if cmd == nil || cmd.Name() != "check" || !cmd.HasParent() {
+		return 0, false
+	}
+
+	fmt.Printf("%s - %v\n", spark.StatusNames[spark.StatusUnknown], err)
+	return spark.StatusUnknown, true
+}
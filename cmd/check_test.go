@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	spark "gospark"
+	"io"
+	"testing"
+)
+
+func TestCheckFailed(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		status  int
+		isCheck bool
+	}{
+		{"unknown flag of check", []string{"check", "--bogus", "1", "2"}, spark.StatusUnknown, true},
+		{"invalid flag value of check", []string{"check", "--warn", "high", "1"}, spark.StatusUnknown, true},
+		{"unknown flag of the root command", []string{"--bogus", "1"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warn, crit int
+			rootCmd := &cobra.Command{Use: "spark", Run: func(*cobra.Command, []string) {}}
+			rootCmd.PersistentFlags().IntVar(&warn, "warn", 0, "")
+			rootCmd.AddCommand(newCheckCmd(&spark.Config{}, &warn, &crit))
+			rootCmd.SetArgs(tt.args)
+			rootCmd.SetOut(io.Discard)
+			rootCmd.SetErr(io.Discard)
+
+			cmd, err := rootCmd.ExecuteC()
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			status, ok := checkFailed(cmd, err)
+			if ok != tt.isCheck || status != tt.status {
+				t.Errorf("got status %d (%t), want %d (%t)", status, ok, tt.status, tt.isCheck)
+			}
+		})
+	}
+}
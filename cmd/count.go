@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	spark "gospark"
+	"os"
+	"time"
+)
+
+func newCountCmd(config *spark.Config, warn, crit *int) *cobra.Command {
+	var bucket time.Duration
+	var timeField int
+	var timeLayout string
+
+	countCmd := &cobra.Command{
+		Use:                   "count [flags]... [event]...",
+		DisableFlagsInUseLine: true,
+		Short:                 "Draw the number of events per time bucket",
+		Long: `Read one event per line, such as a log line, take its timestamp from the --time-field column
+and draw the number of events in every bucket of --bucket, with 0 for the buckets without any.
+
+Timestamps are RFC 3339 or Unix epoch timestamps (in seconds, milliseconds, microseconds or
+nanoseconds), unless a Go layout is given with --time-layout, which spans as many columns as it
+has spaces. Lines without a valid timestamp stop the run, unless --skip-invalid is given.`,
+		Example: `  git log --format=%ad --date=short | spark count --bucket 1d --stats
+ spark count --time-field 4 --time-layout '[02/Jan/2006:15:04:05 -0700]' --bucket 1m < access.log`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyThresholds(cmd, config, warn, crit)
+			config.ColorMode = spark.ResolveColorMode(config.ColorMode, os.Stdout)
+			config.Bucket, config.TimeField, config.TimeLayout = bucket, timeField, timeLayout
+
+			if err := config.Validate(); err != nil {
+				return err
+			}
+
+			times, skipped, err := spark.ParseEvents(args, pipedStdin(), config)
+			reportSkipped(skipped)
+			if err != nil {
+				return err
+			}
+
+			counts, err := spark.CountEvents(times, bucket)
+			if err != nil {
+				return err
+			}
+
+			sparks, err := spark.Spark(counts, config)
+			if err != nil {
+				return err
+			}
+			fmt.Println(sparks)
+
+			return nil
+		},
+	}
+
+	// the config is shared with the root command, whose flags would be overwritten by these defaults
+	countCmd.Flags().Var(newDurationValue(time.Hour, &bucket), "bucket", "duration of a bucket (e.g. 1m or 1d)")
+	countCmd.Flags().IntVar(&timeField, "time-field", 1, "column holding the timestamp of an event (starting at 1)")
+	countCmd.Flags().StringVar(&timeLayout, "time-layout", "", "layout of the timestamps, as a Go time layout (default RFC 3339 or Unix epoch)")
+
+	return countCmd
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	spark "gospark"
+	"time"
+)
+
+// durationValue is a flag value for durations that can also be given in days and weeks
+type durationValue time.Duration
+
+func newDurationValue(value time.Duration, p *time.Duration) *durationValue {
+	*p = value
+	return (*durationValue)(p)
+}
+
+func (d *durationValue) Set(s string) error {
+	v, err := spark.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = durationValue(v)
+	return nil
+}
+
+func (d *durationValue) Type() string {
+	return "duration"
+}
+
+func (d *durationValue) String() string {
+	return time.Duration(*d).String()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+	spark "gospark"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func newListenCmd(config *spark.Config, window *int, warn, crit *int) *cobra.Command {
+	var statsd string
+	var interval time.Duration
+	var sorted bool
+
+	listenCmd := &cobra.Command{
+		Use:   "listen [flags]...",
+		Short: "Draw live sparklines of the StatsD metrics sent to a UDP port",
+		Long: `Listen for StatsD metrics (counters, gauges and timers) on a UDP address, aggregate them per
+metric name into buckets of --interval and redraw one sparkline per metric with its last --window
+buckets in place, until interrupted.
+
+Counters are summed (scaled by their sample rate), gauges keep their last value and timers are
+averaged, with 0 for a bucket without timings. Invalid metrics stop the listener, unless
+--skip-invalid is given.`,
+		Example: `  spark listen --statsd :8125 --interval 1s
+ echo "requests:1|c" | nc -u -w0 localhost 8125`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyThresholds(cmd, config, warn, crit)
+			config.SortSeries = sorted
+			config.ColorMode = spark.ResolveColorMode(config.ColorMode, os.Stdout)
+
+			if err := config.Validate(); err != nil {
+				return err
+			}
+
+			conn, err := net.ListenPacket("udp", statsd)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			skipped, err := spark.Listen(ctx, conn, interval, os.Stdout, *window, config)
+			reportSkipped(skipped)
+			return err
+		},
+	}
+
+	listenCmd.Flags().StringVar(&statsd, "statsd", ":8125", "UDP address to receive StatsD metrics on")
+	listenCmd.Flags().DurationVarP(&interval, "interval", "n", time.Second, "duration of a bucket")
+	listenCmd.Flags().BoolVar(&sorted, "sort", false, "sort the graphs by metric name instead of keeping them in first seen order")
+
+	return listenCmd
+}
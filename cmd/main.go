@@ -1,9 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
 	spark "gospark"
+	"io"
 	"os"
 )
 
@@ -13,6 +15,11 @@ const (
 
 func main() {
 	config := &spark.Config{}
+	var warn, crit int
+	var follow bool
+	var window int
+	var perLine, perColumn, labeled bool
+	var strict bool
 
 	rootCmd := &cobra.Command{
 		Use:                   "spark [flags]... value...",
@@ -25,19 +32,130 @@ Numbers can be separated by any space character, comma, pipe (|) or semi-colon.
 For negative numbers, use the flag separator '--' (flags must come before it).
 
 Sparklines can be colored (background and foreground) with a list of predefined color names:
-black, red, green, yellow, blue, magenta, cyan and white.`,
-		Version: Version,
+black, red, green, yellow, blue, magenta, cyan and white.
+
+A gradient of two or more colors can be given instead of a foreground color, in which case
+each tick is colored according to its value, from the first color (lowest) to the last (highest).
+
+Warning and critical thresholds paint every tick at or above them (or at or below them
+with --threshold-mode below) in the warning and critical colors.
+
+The minimum, maximum and last points can be highlighted with a comma separated style made of
+at most one color and any of the attributes: bold, dim, italic, underline, blink and inverse.
+
+Colors are only written when stdout is a terminal, unless --color is set to always or never.
+In auto mode the NO_COLOR environment variable disables colors and FORCE_COLOR enables them.
+
+Numbers can also be extracted from free-form lines, with --match and a regular expression (its
+group named value, or else its first capture group, is used when it has any, and every group is
+a column with --group-by and --per-column) or with --field for key=value (logfmt) lines, in
+which case units following the number are ignored and lines without a match are skipped.
+
+Several graphs can be drawn at once, stacked with their labels: one per line (--per-line), one
+per column (--per-column), one per label (--labeled) or one per distinct value of a key column
+(--group-by, with the values in the --value column). Each graph is scaled on its own values
+unless --shared-scale is given.
+
+With --prometheus, the input is read in the Prometheus text exposition format (as served on
+/metrics, possibly several scrapes one after the other) and one graph is drawn per series
+matching the selector, such as http_requests_total{code=~"5..",method!="GET"}.
+
+With --influx, the input is read as InfluxDB line protocol and the values of the selected field
+are drawn, with one graph per tag set, or per value of --tag. With --graphite, the input is read
+as Graphite plaintext and one graph is drawn per path matching the pattern, in which * matches
+within a node and {a,b} any of the alternatives, or per value of --tag for tagged paths.
+
+With --bucket, every line holds a timestamp (RFC 3339, Unix epoch in any unit or the Go layout
+given with --time-layout) in the --time-field column and a value in the --value column. Values
+are aggregated into buckets of that duration, and empty buckets are drawn as blank ticks.
+
+With --histogram, the distribution of the values is drawn instead of their sequence: the range
+of the values is split into --bins bins of equal width (picked by the Freedman-Diaconis rule by
+default) and every tick is the number of values in a bin. The stats then include the bin edges.
+
+With --transform, the values are transformed before they are drawn, by any number of transforms
+applied in order: moving averages (sma:N, ema:N), a median filter (median:N), the running total
+(cumsum), the difference (diff) or percent change (pctchange) from the previous value, the
+per-second rate of a counter sampled every interval (rate:10s, by default every second or every
+bucket) and a scale from 0 to 100 (normalize). Stats and thresholds apply to the transformed values.
+
+Invalid tokens stop the run with an error, unless --skip-invalid is given, in which case they
+are dropped and their count is reported on stderr.
+
+Defaults for any flag can be set in a TOML config file, as flag = value pairs of strings,
+booleans, numbers or arrays at the top level or in [profiles.<name>] tables selected with
+--profile, and in GOSPARK_<FLAG> environment variables (GOSPARK_WARN_COLOR for --warn-color).
+Unknown keys and variables are errors. Flags win over the environment, which wins over the config
+file.
+
+With --follow, stdin is read until it is closed and the graph of the last --window values is
+redrawn in place as each line arrives, rescaled to the values currently in the window. Values
+cannot be given as arguments with --follow.`,
+		Version:       Version,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			// flags were parsed fine, so any error from here on is about the settings or the data
+			cmd.SilenceUsage = true
+			if err := applySettings(cmd); err != nil {
+				return err
+			}
+			// --strict is only useful to override a skip-invalid setting
+			if strict {
+				config.SkipInvalid = false
+			}
+			return nil
+		},
 		Example: `  spark 1 5 22 13 53               => ▁▁▃▂█
  spark 0,30,55,80,33,150 --sum    => ▁▂▃▄▂█ (sum:348)
  echo "9 13 5 17 1" | spark       => ▄▆▂█▁
  spark "1|2|3|4|5" --stats        => ▁▂▄▆█ (min:1 max:5 avg:3.00)
- spark --sum -- -5 -1 0 1 5       => ▁▃▄▅█ (sum:0)`,
-		RunE: func(_ *cobra.Command, args []string) error {
+ spark --sum -- -5 -1 0 1 5       => ▁▃▄▅█ (sum:0)
+ spark 1 5 9 -g green,yellow,red  => ▁▄█ (green, yellow, red)
+ spark 10 75 95 --warn 70 --crit 90 --breaches => ▁▆█ (warn:1 crit:1)
+ tail -f metrics.log | spark --follow --window 60 --stats
+ echo "req=/api latency=123ms status=200" | spark --field latency
+ cat app.log | spark --match 'took (\d+)ms'
+ printf "web: 1 5 3\ndb: 9 2 4\n" | spark --labeled --shared-scale
+ printf "hostA 12\nhostB 7\nhostA 15\n" | spark --group-by 1 --value 2 --sort
+ spark --bucket 1m --aggregate max < latency.log
+ spark --histogram --bins 4 --stats 1 2 2 3 9 => █▃▁▃ (min:1 max:9 avg:3.40 edges:1,3,5,7,9)
+ spark --transform diff 1 3 6 10 15 => ▁▃▅█
+ spark 10 9 7 8 5 3 --trend       => █▇▅▆▃▁ (trend:↘-1.31)`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyThresholds(cmd, config, &warn, &crit)
+			config.ColorMode = spark.ResolveColorMode(config.ColorMode, os.Stdout)
+			config.SeriesMode = seriesMode(perLine, perColumn, labeled)
+
 			if err := config.Validate(); err != nil {
 				return err
 			}
 
-			data, err := spark.ValidateArgs(args, os.Stdin)
+			multiSeries := config.SeriesMode != "" || config.GroupBy > 0 || config.Prometheus != "" || config.Influx != "" || config.Graphite != ""
+
+			if follow && multiSeries {
+				return fmt.Errorf("--follow cannot draw several series")
+			}
+			if follow && config.Bucket > 0 {
+				return fmt.Errorf("--follow cannot draw time buckets")
+			}
+			if follow && len(args) > 0 {
+				return fmt.Errorf("--follow reads values from stdin, not arguments")
+			}
+			if follow {
+				skipped, err := spark.Follow(os.Stdin, os.Stdout, window, config)
+				reportSkipped(skipped)
+				return err
+			}
+
+			if multiSeries {
+				return drawSeries(args, config)
+			}
+			if config.Bucket > 0 {
+				return drawBuckets(args, config)
+			}
+
+			data, err := parseArgs(args, config)
 			if err != nil {
 				return err
 			}
@@ -52,14 +170,166 @@ black, red, green, yellow, blue, magenta, cyan and white.`,
 		},
 	}
 
-	rootCmd.Flags().StringVarP(&config.BgColor, "bgcolor", "b", "", "background color of the sparkline graph")
-	rootCmd.Flags().StringVarP(&config.FgColor, "fgcolor", "f", "", "foreground color of the sparkline graph")
-	rootCmd.Flags().BoolVarP(&config.ShowSum, "sum", "s", false, "show sum of points")
-	rootCmd.Flags().BoolVarP(&config.ShowStats, "stats", "t", false, "show stats (min, max and avg)")
-	rootCmd.Flags().BoolVarP(&config.Reverse, "reverse", "r", false, "reverse the graph")
-	rootCmd.Flags().BoolVarP(&config.Vertical, "vertical", "v", false, "show vertical graph")
+	rootCmd.PersistentFlags().String("config", "", "config file with default settings (default $XDG_CONFIG_HOME/gospark/config.toml)")
+	rootCmd.PersistentFlags().StringP("profile", "p", "", "named profile of the config file to apply")
+	rootCmd.PersistentFlags().StringVarP(&config.BgColor, "bgcolor", "b", "", "background color of the sparkline graph")
+	rootCmd.PersistentFlags().StringVarP(&config.FgColor, "fgcolor", "f", "", "foreground color of the sparkline graph")
+	rootCmd.PersistentFlags().StringSliceVarP(&config.Gradient, "gradient", "g", nil, "color ticks by value using two or more comma separated colors, from lowest to highest")
+	rootCmd.PersistentFlags().IntVar(&warn, "warn", 0, "warning threshold, ticks breaching it use the warning color")
+	rootCmd.PersistentFlags().IntVar(&crit, "crit", 0, "critical threshold, ticks breaching it use the critical color")
+	rootCmd.PersistentFlags().StringVar(&config.WarnColor, "warn-color", "yellow", "color of ticks breaching the warning threshold")
+	rootCmd.PersistentFlags().StringVar(&config.CritColor, "crit-color", "red", "color of ticks breaching the critical threshold")
+	rootCmd.PersistentFlags().StringVar(&config.ThresholdMode, "threshold-mode", spark.ThresholdAbove, "whether values breach thresholds when above or below them (above, below)")
+	rootCmd.PersistentFlags().BoolVar(&config.ShowBreaches, "breaches", false, "show the count of points breaching each threshold")
+	rootCmd.PersistentFlags().StringSliceVar(&config.MinStyle, "min-style", nil, "highlight the minimum point with a color and/or attributes (e.g. blue,bold)")
+	rootCmd.PersistentFlags().StringSliceVar(&config.MaxStyle, "max-style", nil, "highlight the maximum point with a color and/or attributes (e.g. red,bold)")
+	rootCmd.PersistentFlags().StringSliceVar(&config.LastStyle, "last-style", nil, "highlight the last point with a color and/or attributes (e.g. underline)")
+	rootCmd.PersistentFlags().StringSliceVar(&config.Transforms, "transform", nil, "transform the values before drawing them, in order (sma:N, ema:N, median:N, cumsum, diff, rate[:interval], pctchange, normalize)")
+	rootCmd.PersistentFlags().StringVar(&config.ColorMode, "color", spark.ColorAuto, "when to use colors (auto, always, never)")
+	rootCmd.PersistentFlags().BoolVarP(&config.ShowSum, "sum", "s", false, "show sum of points")
+	rootCmd.PersistentFlags().BoolVarP(&config.ShowStats, "stats", "t", false, "show stats (min, max and avg)")
+	rootCmd.PersistentFlags().BoolVar(&config.ShowTrend, "trend", false, "show the least-squares trend, as an arrow when it rises or falls beyond the noise and the slope per point")
+	rootCmd.PersistentFlags().BoolVarP(&config.Reverse, "reverse", "r", false, "reverse the graph")
+	rootCmd.PersistentFlags().BoolVarP(&config.Vertical, "vertical", "v", false, "show vertical graph")
+
+	rootCmd.PersistentFlags().BoolVar(&config.SkipInvalid, "skip-invalid", false, "skip tokens that are not valid numbers and report how many were dropped")
+	rootCmd.PersistentFlags().StringVarP(&config.Match, "match", "m", "", "extract numbers from each line with a regular expression, using its first capture group (or the one named value) if any")
+	rootCmd.PersistentFlags().StringVar(&config.Field, "field", "", "extract the number of a key=value field from each line (logfmt)")
+	rootCmd.MarkFlagsMutuallyExclusive("match", "field")
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "fail on the first token that is not a valid number (default)")
+	rootCmd.MarkFlagsMutuallyExclusive("skip-invalid", "strict")
+	rootCmd.Flags().BoolVar(&perLine, "per-line", false, "draw one graph per input line")
+	rootCmd.Flags().BoolVar(&perColumn, "per-column", false, "draw one graph per column, named after the header line if there is one")
+	rootCmd.Flags().BoolVar(&labeled, "labeled", false, "draw one graph per label, from lines formatted as label: values")
+	rootCmd.MarkFlagsMutuallyExclusive("per-line", "per-column", "labeled")
+	rootCmd.Flags().IntVar(&config.GroupBy, "group-by", 0, "draw one graph per distinct value of this column (starting at 1)")
+	rootCmd.Flags().IntVar(&config.ValueColumn, "value", 0, "column holding the values when grouping or bucketing (default the first column other than the group-by or time ones)")
+	rootCmd.Flags().BoolVar(&config.SortSeries, "sort", false, "sort the graphs by label instead of keeping them in first seen order")
+	rootCmd.MarkFlagsMutuallyExclusive("group-by", "per-line")
+	rootCmd.MarkFlagsMutuallyExclusive("group-by", "per-column")
+	rootCmd.MarkFlagsMutuallyExclusive("group-by", "labeled")
+	rootCmd.Flags().StringVar(&config.Prometheus, "prometheus", "", "read the Prometheus text format and draw one graph per series matching this selector (e.g. 'up{job=\"api\"}')")
+	rootCmd.Flags().StringVar(&config.Influx, "influx", "", "read InfluxDB line protocol and draw this field, as measurement.field or field")
+	rootCmd.Flags().StringVar(&config.Graphite, "graphite", "", "read Graphite plaintext and draw the paths matching this pattern (e.g. 'servers.*.cpu')")
+	rootCmd.Flags().StringVar(&config.Tag, "tag", "", "draw one graph per value of this tag with --influx or --graphite")
+	rootCmd.MarkFlagsMutuallyExclusive("prometheus", "influx", "graphite")
+	rootCmd.Flags().Var(newDurationValue(0, &config.Bucket), "bucket", "read timestamped lines and draw one tick per bucket of this duration (e.g. 1m or 1d), blank when empty")
+	rootCmd.Flags().StringVarP(&config.Aggregation, "aggregate", "a", "avg", "value of a bucket (first, last, min, max, avg, sum, count or a percentile such as p95)")
+	rootCmd.Flags().IntVar(&config.TimeField, "time-field", 1, "column holding the timestamp of a line (starting at 1)")
+	rootCmd.Flags().StringVar(&config.TimeLayout, "time-layout", "", "layout of the timestamps, as a Go time layout (default RFC 3339 or Unix epoch)")
+	rootCmd.Flags().BoolVar(&config.Histogram, "histogram", false, "draw the distribution of the values, one tick per bin counting the values in it")
+	rootCmd.Flags().IntVar(&config.Bins, "bins", 0, "number of bins of the histogram (default picked by the Freedman-Diaconis rule)")
+	rootCmd.Flags().BoolVar(&config.SharedScale, "shared-scale", false, "draw all the graphs on the same scale so that they can be compared")
+	rootCmd.Flags().BoolVar(&follow, "follow", false, "keep reading stdin and redraw the graph in place as values arrive")
+	rootCmd.PersistentFlags().IntVar(&window, "window", 40, "number of most recent values drawn when following or watching")
 
-	if rootCmd.Execute() != nil {
+	rootCmd.AddCommand(newCheckCmd(config, &warn, &crit))
+	rootCmd.AddCommand(newWatchCmd(config, &window, &warn, &crit))
+	rootCmd.AddCommand(newServeCmd(config, &warn, &crit))
+	rootCmd.AddCommand(newListenCmd(config, &window, &warn, &crit))
+	rootCmd.AddCommand(newPromCmd(config, &window, &warn, &crit))
+	rootCmd.AddCommand(newCountCmd(config, &warn, &crit))
+
+	if cmd, err := rootCmd.ExecuteC(); err != nil {
+		if status, ok := checkFailed(cmd, err); ok {
+			os.Exit(status)
+		}
+		printError(err)
 		os.Exit(1)
 	}
 }
+
+func seriesMode(perLine, perColumn, labeled bool) string {
+	switch {
+	case perLine:
+		return spark.SeriesLines
+	case perColumn:
+		return spark.SeriesColumns
+	case labeled:
+		return spark.SeriesLabels
+	}
+	return ""
+}
+
+func drawSeries(args []string, config *spark.Config) error {
+	series, skipped, err := spark.ParseSeries(args, pipedStdin(), config)
+	reportSkipped(skipped)
+	if err != nil {
+		return err
+	}
+
+	sparks, err := spark.SparkSeries(series, config)
+	if err != nil {
+		return err
+	}
+	fmt.Println(sparks)
+
+	return nil
+}
+
+func drawBuckets(args []string, config *spark.Config) error {
+	points, skipped, err := spark.ParsePoints(args, pipedStdin(), config)
+	reportSkipped(skipped)
+	if err != nil {
+		return err
+	}
+
+	data, gaps, err := spark.BucketPoints(points, config.Bucket, config.Aggregation)
+	if err != nil {
+		return err
+	}
+
+	sparks, err := spark.SparkGaps(data, gaps, config)
+	if err != nil {
+		return err
+	}
+	fmt.Println(sparks)
+
+	return nil
+}
+
+// pipedStdin returns stdin when data is piped into it, or nil when it is a terminal
+func pipedStdin() io.Reader {
+	stat, err := os.Stdin.Stat()
+	if err != nil || stat.Mode()&os.ModeCharDevice != 0 {
+		return nil
+	}
+	return os.Stdin
+}
+
+// parseArgs parses the input and reports on stderr how many invalid tokens were skipped
+func parseArgs(args []string, config *spark.Config) ([]int, error) {
+	data, skipped, err := spark.ParseArgs(args, os.Stdin, config)
+	reportSkipped(skipped)
+	return data, err
+}
+
+func reportSkipped(skipped int) {
+	if skipped > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "skipped %d invalid token(s)\n", skipped)
+	}
+}
+
+// printError prints parse errors compiler-style, with the input they come from and their position
+func printError(err error) {
+	var parseErr *spark.ParseError
+	if errors.As(err, &parseErr) {
+		source := parseErr.Source
+		if source == "" {
+			source = "<stdin>"
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "%s:%d:%d: error: %v: %s\n", source, parseErr.Line, parseErr.Column, parseErr.Err, parseErr.Token)
+		return
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+}
+
+func applyThresholds(cmd *cobra.Command, config *spark.Config, warn, crit *int) {
+	if cmd.Flags().Changed("warn") {
+		config.Warn = warn
+	}
+	if cmd.Flags().Changed("crit") {
+		config.Crit = crit
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	spark "gospark"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func newPromCmd(config *spark.Config, window *int, warn, crit *int) *cobra.Command {
+	var address, query string
+	var duration, step, timeout time.Duration
+	var sorted bool
+
+	promCmd := &cobra.Command{
+		Use:   "prom [flags]...",
+		Short: "Draw the series returned by a Prometheus range query",
+		Long: `Evaluate a PromQL query over the last --range with the /api/v1/query_range endpoint of a
+Prometheus compatible server and draw one sparkline per returned series, with its labels.
+
+The step defaults to the range divided into --window points.`,
+		Example: `  spark prom --query 'rate(http_requests_total[5m])' --range 1h
+ spark prom --url http://prometheus:9090 --query 'node_load1' --range 6h --step 5m --stats`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyThresholds(cmd, config, warn, crit)
+			config.SortSeries = sorted
+			config.ColorMode = spark.ResolveColorMode(config.ColorMode, os.Stdout)
+
+			if err := config.Validate(); err != nil {
+				return err
+			}
+
+			if step == 0 {
+				step = spark.DefaultStep(duration, *window)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			end := time.Now()
+			client := &http.Client{Timeout: timeout}
+			series, skipped, err := spark.QueryRange(ctx, client, address, query, end.Add(-duration), end, step, config)
+			reportSkipped(skipped)
+			if err != nil {
+				return err
+			}
+
+			config.Interval = step
+			sparks, err := spark.SparkSeries(series, config)
+			if err != nil {
+				return err
+			}
+			fmt.Println(sparks)
+
+			return nil
+		},
+	}
+
+	promCmd.Flags().StringVar(&address, "url", "http://localhost:9090", "base URL of the Prometheus compatible server")
+	promCmd.Flags().StringVarP(&query, "query", "q", "", "PromQL query to evaluate")
+	promCmd.Flags().Var(newDurationValue(time.Hour, &duration), "range", "how far back the query is evaluated (e.g. 1h or 7d)")
+	promCmd.Flags().Var(newDurationValue(0, &step), "step", "time between two points (e.g. 5m or 1d), the range divided into --window points when unset")
+	promCmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "timeout of the request")
+	promCmd.Flags().BoolVar(&sorted, "sort", false, "sort the graphs by labels instead of keeping them in the order returned")
+	_ = promCmd.MarkFlagRequired("query")
+
+	return promCmd
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	spark "gospark"
+	"net/http"
+	"os"
+	"time"
+)
+
+// timeouts of the server, so that slow or idle clients cannot hold connections open forever
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 10 * time.Second
+	writeTimeout      = 30 * time.Second
+)
+
+func newServeCmd(config *spark.Config, warn, crit *int) *cobra.Command {
+	var addr string
+
+	serveCmd := &cobra.Command{
+		Use:   "serve [flags]...",
+		Short: "Serve sparklines over HTTP",
+		Long: `Serve sparklines over HTTP at GET /spark, drawing the values given in the values parameter.
+
+The other parameters are named like the flags (fg and bg for the colors) and default to the
+flags given to serve. The response is text, JSON, SVG or PNG according to the format parameter
+or else the Accept header. Text responses only contain colors with color=always, and images
+draw at most 4096 values.`,
+		Example: `  spark serve --addr :8080
+ curl 'localhost:8080/spark?values=1,5,22&stats=1'       => ▁▂█ (min:1 max:22 avg:9.33)
+ curl 'localhost:8080/spark?values=1,5,22&fg=red&format=svg'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyThresholds(cmd, config, warn, crit)
+
+			if err := config.Validate(); err != nil {
+				return err
+			}
+
+			server := &http.Server{
+				Addr:              addr,
+				Handler:           spark.NewHandler(config),
+				ReadHeaderTimeout: readHeaderTimeout,
+				ReadTimeout:       readTimeout,
+				WriteTimeout:      writeTimeout,
+			}
+
+			_, _ = fmt.Fprintf(os.Stderr, "serving sparklines on %s\n", addr)
+			return server.ListenAndServe()
+		},
+	}
+
+	serveCmd.Flags().StringVar(&addr, "addr", ":8080", "address to listen on")
+
+	return serveCmd
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	spark "gospark"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// mutuallyExclusiveAnnotation is the annotation cobra.Command.MarkFlagsMutuallyExclusive lists the
+// groups of a flag in, as space separated flag names
+const mutuallyExclusiveAnnotation = "cobra_annotation_mutually_exclusive"
+
+// applySettings sets the flags that were not given on the command line from the GOSPARK_*
+// environment variables, or else from the config file and its selected profile. A setting is
+// ignored when a flag mutually exclusive with it is already set, so that flags still win.
+func applySettings(cmd *cobra.Command) error {
+	env := spark.EnvSettings(os.Environ())
+
+	path, required := cmd.Flag("config").Value.String(), cmd.Flags().Changed("config")
+	if envPath, exists := env["config"]; !required && exists {
+		path, required = envPath, true
+	}
+	if !required {
+		defaultPath, err := spark.DefaultConfigPath()
+		if err != nil {
+			// without a home directory there is no default config file to read
+			defaultPath = ""
+		}
+		path = defaultPath
+	}
+
+	profile := cmd.Flag("profile").Value.String()
+	if envProfile, exists := env["profile"]; !cmd.Flags().Changed("profile") && exists {
+		profile = envProfile
+	}
+
+	settings := map[string]string{}
+	if path != "" || profile != "" {
+		var err error
+		if settings, err = spark.LoadSettings(path, profile, required); err != nil {
+			return err
+		}
+	}
+
+	known := flagNames(cmd.Root())
+	for key := range settings {
+		if !known[key] {
+			return fmt.Errorf("%s: unknown setting: %s", path, key)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		if !known[key] {
+			return fmt.Errorf("%s: unknown setting: %s", spark.EnvVariable(key), key)
+		}
+	}
+
+	// the environment is applied before the file, so that it wins within a group of exclusive flags
+	for _, source := range []map[string]string{env, settings} {
+		delete(source, "config")
+		delete(source, "profile")
+		for _, key := range slices.Sorted(maps.Keys(source)) {
+			flag := cmd.Flags().Lookup(key)
+			if flag == nil || flag.Changed || excludedFlag(cmd, flag) {
+				continue
+			}
+			if err := cmd.Flags().Set(key, source[key]); err != nil {
+				return fmt.Errorf("invalid setting %s: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// excludedFlag reports whether a flag mutually exclusive with flag is already set
+func excludedFlag(cmd *cobra.Command, flag *pflag.Flag) bool {
+	for _, group := range flag.Annotations[mutuallyExclusiveAnnotation] {
+		for _, name := range strings.Fields(group) {
+			if other := cmd.Flags().Lookup(name); other != nil && other != flag && other.Changed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flagNames returns the names of the flags of every command
+func flagNames(cmd *cobra.Command) map[string]bool {
+	names := map[string]bool{}
+	visit := func(flag *pflag.Flag) {
+		names[flag.Name] = true
+	}
+
+	cmd.LocalFlags().VisitAll(visit)
+	for _, child := range cmd.Commands() {
+		maps.Copy(names, flagNames(child))
+	}
+	return names
+}
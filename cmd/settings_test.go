@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplySettingsExclusiveFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		file     string
+		expected map[string]bool
+	}{
+		{
+			name:     "flag wins over the environment",
+			args:     []string{"--strict"},
+			env:      map[string]string{"GOSPARK_SKIP_INVALID": "true"},
+			expected: map[string]bool{"strict": true, "skip-invalid": false},
+		},
+		{
+			name:     "flag wins over the file",
+			args:     []string{"--labeled"},
+			file:     "per-line = true\n",
+			expected: map[string]bool{"labeled": true, "per-line": false},
+		},
+		{
+			name:     "environment wins over the file",
+			env:      map[string]string{"GOSPARK_STRICT": "true"},
+			file:     "skip-invalid = true\n",
+			expected: map[string]bool{"strict": true, "skip-invalid": false},
+		},
+		{
+			name:     "defaults apply without conflicts",
+			env:      map[string]string{"GOSPARK_SKIP_INVALID": "true"},
+			file:     "per-line = true\n",
+			expected: map[string]bool{"skip-invalid": true, "per-line": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", dir)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if tt.file != "" {
+				path := filepath.Join(dir, "gospark", "config.toml")
+				if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+					t.Fatalf("failed to create config dir: %v", err)
+				}
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatalf("failed to write config file: %v", err)
+				}
+			}
+
+			flags := map[string]*bool{}
+			rootCmd := &cobra.Command{
+				Use:               "spark",
+				PersistentPreRunE: func(cmd *cobra.Command, _ []string) error { return applySettings(cmd) },
+				Run:               func(*cobra.Command, []string) {},
+			}
+			rootCmd.PersistentFlags().String("config", "", "")
+			rootCmd.PersistentFlags().String("profile", "", "")
+			for _, name := range []string{"skip-invalid", "strict", "per-line", "labeled"} {
+				flags[name] = rootCmd.Flags().Bool(name, false, "")
+			}
+			rootCmd.MarkFlagsMutuallyExclusive("skip-invalid", "strict")
+			rootCmd.MarkFlagsMutuallyExclusive("per-line", "labeled")
+			rootCmd.SetArgs(tt.args)
+			rootCmd.SetOut(io.Discard)
+			rootCmd.SetErr(io.Discard)
+
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for name, expected := range tt.expected {
+				if *flags[name] != expected {
+					t.Errorf("got %s = %t, want %t", name, *flags[name], expected)
+				}
+			}
+		})
+	}
+}
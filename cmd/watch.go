@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+	spark "gospark"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func newWatchCmd(config *spark.Config, window *int, warn, crit *int) *cobra.Command {
+	var interval time.Duration
+
+	watchCmd := &cobra.Command{
+		Use:                   "watch [flags]... -- command [arg]...",
+		DisableFlagsInUseLine: true,
+		Short:                 "Run a command periodically and draw its output",
+		Long: `Run a command every interval, parse the numbers it prints like any other input and redraw
+the sparkline of the last --window values in place, until interrupted. A run that fails or prints
+no numbers is reported on stderr and the next runs go on.`,
+		Example: `  spark watch --interval 2s -- sh -c "cut -d ' ' -f1 /proc/loadavg"
+ spark watch --window 60 --stats -- curl -s -o /dev/null -w "%{time_total}" example.com`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyThresholds(cmd, config, warn, crit)
+			config.ColorMode = spark.ResolveColorMode(config.ColorMode, os.Stdout)
+
+			if err := config.Validate(); err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			skipped, err := spark.Watch(ctx, args, interval, os.Stdout, os.Stderr, *window, config)
+			reportSkipped(skipped)
+			return err
+		},
+	}
+
+	watchCmd.Flags().DurationVarP(&interval, "interval", "n", time.Second, "time between two runs of the command")
+
+	return watchCmd
+}
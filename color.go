@@ -1,6 +1,16 @@
 package spark
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
 
 var (
 	ColorMap = map[string]int{
@@ -13,6 +23,15 @@ var (
 		"cyan":    6,
 		"white":   7,
 	}
+
+	AttributeMap = map[string]int{
+		"bold":      1,
+		"dim":       2,
+		"italic":    3,
+		"underline": 4,
+		"blink":     5,
+		"inverse":   7,
+	}
 )
 
 func ValidateColor(color string) error {
@@ -21,8 +40,88 @@ func ValidateColor(color string) error {
 	}
 
 	if _, exists := ColorMap[color]; !exists {
-		return fmt.Errorf("invalid color: %s", color)
+		return &ColorError{Color: color}
+	}
+
+	return nil
+}
+
+func ValidateGradient(stops []string) error {
+	if len(stops) == 0 {
+		return nil
+	}
+
+	if len(stops) < 2 {
+		return fmt.Errorf("gradient needs at least two colors: %s", stops[0])
+	}
+
+	for _, color := range stops {
+		if color == "" {
+			return fmt.Errorf("invalid color: gradient stops cannot be empty")
+		}
+		if err := ValidateColor(color); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ValidateStyle(style []string) error {
+	hasColor := false
+	for _, name := range style {
+		if _, exists := AttributeMap[name]; exists {
+			continue
+		}
+
+		if _, exists := ColorMap[name]; !exists {
+			return fmt.Errorf("invalid style: %s", name)
+		}
+
+		if hasColor {
+			return fmt.Errorf("invalid style: more than one color in %s", strings.Join(style, ","))
+		}
+		hasColor = true
 	}
 
 	return nil
 }
+
+func ValidateColorMode(mode string) error {
+	if mode == "" || mode == ColorAuto || mode == ColorAlways || mode == ColorNever {
+		return nil
+	}
+
+	return fmt.Errorf("invalid color mode: %s", mode)
+}
+
+// ResolveColorMode turns the auto mode into always or never for output written to out, as told by
+// ShouldColor. Other modes are returned as they are. The library cannot tell where its output goes,
+// so it draws auto mode without colors unless the mode was resolved first.
+func ResolveColorMode(mode string, out *os.File) string {
+	if mode != ColorAuto {
+		return mode
+	}
+	if ShouldColor(out) {
+		return ColorAlways
+	}
+	return ColorNever
+}
+
+// ShouldColor reports whether colors should be written to out in auto mode.
+// NO_COLOR disables colors, FORCE_COLOR enables them, otherwise out must be a terminal.
+func ShouldColor(out *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	if force := os.Getenv("FORCE_COLOR"); force != "" {
+		return force != "0" && force != "false"
+	}
+
+	stat, err := out.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) != 0
+}
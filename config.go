@@ -1,12 +1,48 @@
 package spark
 
+import (
+	"time"
+)
+
 type Config struct {
-	BgColor   string
-	FgColor   string
-	ShowSum   bool
-	ShowStats bool
-	Reverse   bool
-	Vertical  bool
+	BgColor       string
+	FgColor       string
+	Gradient      []string
+	Warn          *int
+	Crit          *int
+	WarnColor     string
+	CritColor     string
+	ThresholdMode string
+	MinStyle      []string
+	MaxStyle      []string
+	LastStyle     []string
+	ColorMode     string
+	SkipInvalid   bool
+	Match         string
+	Field         string
+	SeriesMode    string
+	SharedScale   bool
+	GroupBy       int
+	ValueColumn   int
+	SortSeries    bool
+	Prometheus    string
+	Influx        string
+	Graphite      string
+	Tag           string
+	Bucket        time.Duration
+	Interval      time.Duration // time between two points when known without buckets, for rate
+	Aggregation   string
+	TimeField     int
+	TimeLayout    string
+	Histogram     bool
+	Bins          int
+	Transforms    []string
+	ShowSum       bool
+	ShowStats     bool
+	ShowBreaches  bool
+	ShowTrend     bool
+	Reverse       bool
+	Vertical      bool
 }
 
 func (c *Config) Validate() error {
@@ -17,5 +53,68 @@ func (c *Config) Validate() error {
 	if err = ValidateColor(c.FgColor); err != nil {
 		return err
 	}
+	if err = ValidateGradient(c.Gradient); err != nil {
+		return err
+	}
+	if err = validateThresholds(c); err != nil {
+		return err
+	}
+	if err = ValidateColorMode(c.ColorMode); err != nil {
+		return err
+	}
+	if err = ValidateSeriesMode(c.SeriesMode); err != nil {
+		return err
+	}
+	if err = validateGroups(c); err != nil {
+		return err
+	}
+	if _, err = c.matcher(); err != nil {
+		return err
+	}
+	if err = validateInputFormat(c); err != nil {
+		return err
+	}
+	if err = validateBuckets(c); err != nil {
+		return err
+	}
+	if err = validateHistogram(c); err != nil {
+		return err
+	}
+	if err = validateTransforms(c); err != nil {
+		return err
+	}
+	if c.Prometheus != "" {
+		if _, err = ParseSelector(c.Prometheus); err != nil {
+			return err
+		}
+	}
+	if c.Graphite != "" {
+		if _, err = graphitePattern(c.Graphite); err != nil {
+			return err
+		}
+	}
+	for _, style := range [][]string{c.MinStyle, c.MaxStyle, c.LastStyle} {
+		if err = ValidateStyle(style); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// colorEnabled is false for auto mode, which callers that know their output resolve with
+// ResolveColorMode
+func (c *Config) colorEnabled() bool {
+	return c.ColorMode != ColorNever && c.ColorMode != ColorAuto
+}
+
+// valueColumn defaults to the first column that is neither the group-by column nor a time column
+func (c *Config) valueColumn() int {
+	if c.ValueColumn > 0 {
+		return c.ValueColumn
+	}
+	column := 1
+	for column == c.GroupBy || (c.Bucket > 0 && column >= c.timeField() && column < c.timeField()+c.timeColumns()) {
+		column++
+	}
+	return column
+}
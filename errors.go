@@ -0,0 +1,74 @@
+package spark
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoData        = errors.New("no numeric data provided - specify numbers as arguments or pipe data via stdin")
+	ErrInvalidNumber = errors.New("invalid number")
+	ErrInfinite      = errors.New("infinite numbers not supported")
+	ErrNaN           = errors.New("NaN (not a number) not supported")
+	ErrTooLarge      = errors.New("number is too large")
+	ErrTooSmall      = errors.New("number is too short")
+	ErrColumnCount   = errors.New("wrong number of columns")
+	ErrMissingLabel  = errors.New("missing label, expected label: values")
+	ErrOverflow      = errors.New("numbers are too large, sum would overflow")
+	ErrUnderflow     = errors.New("numbers are too large, sum would underflow")
+	ErrInvalidMetric = errors.New("invalid metric")
+	ErrInvalidSample = errors.New("invalid sample")
+	ErrInvalidPoint  = errors.New("invalid point")
+	ErrInvalidTime   = errors.New("invalid time")
+	ErrZeroChange    = errors.New("percent change from 0")
+	ErrNoValuesLeft  = errors.New("transforms left no values to draw")
+)
+
+// ParseError reports a token that could not be parsed as a number. Lines and columns start at 1,
+// columns count characters, and for command line arguments the line is the argument position.
+type ParseError struct {
+	Source string
+	Line   int
+	Column int
+	Token  string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v: %s", e.Line, e.Column, e.Err, e.Token)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// StatsError reports the data point at which the stats could not be computed, Index starts at 0.
+type StatsError struct {
+	Index int
+	Value int
+	Err   error
+}
+
+func (e *StatsError) Error() string {
+	return fmt.Sprintf("point %d (%d): %v", e.Index+1, e.Value, e.Err)
+}
+
+func (e *StatsError) Unwrap() error {
+	return e.Err
+}
+
+type ColorError struct {
+	Color string
+}
+
+func (e *ColorError) Error() string {
+	return fmt.Sprintf("invalid color: %s", e.Color)
+}
+
+func setSource(err error, source string) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		parseErr.Source = source
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package spark
+
+import (
+	"errors"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestParseSourceErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   []string
+		line     int
+		column   int
+		token    string
+		err      error
+		errorMsg string
+	}{
+		{"invalid number in second argument", []string{"1", "abc"}, 2, 1, "abc", ErrInvalidNumber, "line 2, column 1: invalid number: abc"},
+		{"invalid number inside argument", []string{"1 2,x3"}, 1, 5, "x3", ErrInvalidNumber, "line 1, column 5: invalid number: x3"},
+		{"column counts characters", []string{"é 1"}, 1, 1, "é", ErrInvalidNumber, "line 1, column 1: invalid number: é"},
+		{"infinite number", []string{"1", "2", "  -inf"}, 3, 3, "-inf", ErrInfinite, "line 3, column 3: infinite numbers not supported: -inf"},
+		{"NaN", []string{"NaN"}, 1, 1, "NaN", ErrNaN, "line 1, column 1: NaN (not a number) not supported: NaN"},
+		{"too large", []string{"1e19"}, 1, 1, "1e19", ErrTooLarge, "line 1, column 1: number is too large: 1e19"},
+		{"too small", []string{"-1e19"}, 1, 1, "-1e19", ErrTooSmall, "line 1, column 1: number is too short: -1e19"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseSource(tt.source, &Config{})
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a *ParseError, got %v", err)
+			}
+			if parseErr.Line != tt.line || parseErr.Column != tt.column || parseErr.Token != tt.token {
+				t.Errorf("got line %d, column %d, token %s, want line %d, column %d, token %s", parseErr.Line, parseErr.Column, parseErr.Token, tt.line, tt.column, tt.token)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected error to wrap '%v', got '%v'", tt.err, err)
+			}
+			if err.Error() != tt.errorMsg {
+				t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestValidateArgsErrorSource(t *testing.T) {
+	_, err := ValidateArgs([]string{"1", "abc"}, os.Stdin)
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a *ParseError, got %v", err)
+	}
+	if parseErr.Source != "<args>" {
+		t.Errorf("got source '%s', want '<args>'", parseErr.Source)
+	}
+
+	_, err = ValidateArgs(nil, os.Stdin)
+	if !errors.Is(err, ErrNoData) {
+		t.Errorf("expected ErrNoData, got %v", err)
+	}
+}
+
+func TestStatsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []int
+		index    int
+		err      error
+		errorMsg string
+	}{
+		{"overflow", []int{1, math.MaxInt, 2}, 1, ErrOverflow, "point 2 (9223372036854775807): numbers are too large, sum would overflow"},
+		{"underflow", []int{-1, -2, math.MinInt}, 2, ErrUnderflow, "point 3 (-9223372036854775808): numbers are too large, sum would underflow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Spark(tt.data, &Config{})
+
+			var statsErr *StatsError
+			if !errors.As(err, &statsErr) {
+				t.Fatalf("expected a *StatsError, got %v", err)
+			}
+			if statsErr.Index != tt.index {
+				t.Errorf("got index %d, want %d", statsErr.Index, tt.index)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected error to wrap '%v', got '%v'", tt.err, err)
+			}
+			if err.Error() != tt.errorMsg {
+				t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestColorErrors(t *testing.T) {
+	err := (&Config{Gradient: []string{"green", "purple"}}).Validate()
+
+	var colorErr *ColorError
+	if !errors.As(err, &colorErr) {
+		t.Fatalf("expected a *ColorError, got %v", err)
+	}
+	if colorErr.Color != "purple" {
+		t.Errorf("got color '%s', want 'purple'", colorErr.Color)
+	}
+}
@@ -0,0 +1,72 @@
+package spark
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// fieldValue matches the numeric part of a logfmt value, so units like "123ms" are ignored
+const fieldValue = `="?([-+]?(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][-+]?\d+)?)`
+
+// matcher returns the regular expression extracting numbers from lines, or nil when lines are
+// split around separators.
+func (c *Config) matcher() (*regexp.Regexp, error) {
+	if c.Match != "" && c.Field != "" {
+		return nil, fmt.Errorf("match and field cannot be used together")
+	}
+
+	if c.Field != "" {
+		return regexp.MustCompile(`(?:^|[\s,;|])` + regexp.QuoteMeta(c.Field) + fieldValue), nil
+	}
+
+	if c.Match != "" {
+		re, err := regexp.Compile(c.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match pattern: %w", err)
+		}
+		return re, nil
+	}
+
+	return nil, nil
+}
+
+// extractFields returns every match of re in line, or the capture group named value when it has
+// one, or else its first capture group, so that other groups can match units or context
+func extractFields(line string, re *regexp.Regexp) []field {
+	group := 0
+	if i := re.SubexpIndex("value"); i > 0 {
+		group = i
+	} else if re.NumSubexp() > 0 {
+		group = 1
+	}
+
+	var result []field
+	for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		result = append(result, field{token: line[start:end], column: utf8.RuneCountInString(line[:start]) + 1})
+	}
+	return result
+}
+
+// extractColumns returns every capture group of every match of re in line as a column, or the
+// whole matches when it has none, for the modes reading labels and values from columns
+func extractColumns(line string, re *regexp.Regexp) []field {
+	var result []field
+	for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+		if len(m) > 2 {
+			m = m[2:]
+		}
+		for i := 0; i < len(m); i += 2 {
+			start, end := m[i], m[i+1]
+			if start < 0 {
+				continue
+			}
+			result = append(result, field{token: line[start:end], column: utf8.RuneCountInString(line[:start]) + 1})
+		}
+	}
+	return result
+}
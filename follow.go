@@ -0,0 +1,116 @@
+package spark
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Window struct {
+	size int
+	data []int
+}
+
+func NewWindow(size int) (*Window, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("window size must be at least 1: %d", size)
+	}
+
+	return &Window{size: size, data: make([]int, 0, size)}, nil
+}
+
+func (w *Window) Push(values ...int) {
+	w.data = append(w.data, values...)
+	if len(w.data) > w.size {
+		w.data = append(w.data[:0], w.data[len(w.data)-w.size:]...)
+	}
+}
+
+func (w *Window) Values() []int {
+	return w.data
+}
+
+// Follow reads numbers from r as they arrive and, after each one, redraws the sparkline of the last size values
+// in place on w. The graph is rescaled to the minimum and maximum of the window on every redraw. It returns
+// how many invalid tokens were skipped when config.SkipInvalid is set.
+func Follow(r io.Reader, w io.Writer, size int, config *Config) (int, error) {
+	if config.Vertical {
+		return 0, fmt.Errorf("vertical graphs cannot be redrawn in place")
+	}
+
+	window, err := NewWindow(size)
+	if err != nil {
+		return 0, err
+	}
+
+	parser, err := newParser(r, config)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		n, err := parser.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return parser.Skipped(), finishRedraw(w, len(window.Values()) > 0, err)
+		}
+
+		window.Push(n)
+		if err = redraw(w, window.Values(), config); err != nil {
+			return parser.Skipped(), finishRedraw(w, len(window.Values()) > 0, err)
+		}
+	}
+
+	return parser.Skipped(), finishRedraw(w, len(window.Values()) > 0, nil)
+}
+
+func redraw(w io.Writer, data []int, config *Config) error {
+	sparks, err := Spark(data, config)
+	// transforms such as diff need a few values before there is anything to draw
+	if errors.Is(err, ErrNoValuesLeft) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// return to the start of the line, draw, then clear whatever the previous graph left behind
+	_, err = fmt.Fprintf(w, "\r%s\033[K", sparks)
+	return err
+}
+
+// finishRedraw moves past the graph, if any was drawn, so that whatever is printed next starts on its own line
+func finishRedraw(w io.Writer, drawn bool, err error) error {
+	if !drawn {
+		return err
+	}
+
+	if _, printErr := fmt.Fprintln(w); err == nil {
+		err = printErr
+	}
+	return err
+}
+
+// redrawLines draws the lines of text over the previous lines drawn, and returns how many it drew.
+// The cursor is left at the end of the last line.
+func redrawLines(w io.Writer, text string, previous int) (int, error) {
+	lines := strings.Split(text, "\n")
+
+	var b strings.Builder
+	b.WriteString("\r")
+	if previous > 1 {
+		fmt.Fprintf(&b, "\033[%dA", previous-1)
+	}
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line + "\033[K")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return len(lines), err
+}
@@ -0,0 +1,143 @@
+package spark
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		pushes   [][]int
+		expected []int
+	}{
+		{"not full", 3, [][]int{{1}, {2}}, []int{1, 2}},
+		{"exactly full", 3, [][]int{{1}, {2}, {3}}, []int{1, 2, 3}},
+		{"oldest values dropped", 3, [][]int{{1}, {2}, {3}, {4}}, []int{2, 3, 4}},
+		{"several values at once", 3, [][]int{{1, 2}, {3, 4, 5, 6}}, []int{4, 5, 6}},
+		{"single value window", 1, [][]int{{1}, {2}}, []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := NewWindow(tt.size)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, values := range tt.pushes {
+				window.Push(values...)
+			}
+
+			if !sliceEqual(window.Values(), tt.expected) {
+				t.Errorf("got %v, want %v", window.Values(), tt.expected)
+			}
+		})
+	}
+
+	if _, err := NewWindow(0); err == nil || err.Error() != "window size must be at least 1: 0" {
+		t.Errorf("expected window size error, got %v", err)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		size        int
+		config      Config
+		expected    string
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:     "redraws after every value",
+			input:    "1\n5\n3\n",
+			size:     10,
+			expected: "\r▅\033[K\r▁█\033[K\r▁█▄\033[K\n",
+		},
+		{
+			name:     "several values on one line",
+			input:    "1 5\n",
+			size:     10,
+			expected: "\r▅\033[K\r▁█\033[K\n",
+		},
+		{
+			name:     "window rescales",
+			input:    "1\n5\n3\n8\n",
+			size:     3,
+			config:   Config{ShowStats: true},
+			expected: "\r▅ (min:1 max:1 avg:1.00)\033[K\r▁█ (min:1 max:5 avg:3.00)\033[K\r▁█▄ (min:1 max:5 avg:3.00)\033[K\r▃▁█ (min:3 max:8 avg:5.33)\033[K\n",
+		},
+		{
+			name:     "waits for enough values to transform",
+			input:    "1\n5\n3\n",
+			size:     10,
+			config:   Config{Transforms: []string{"diff"}},
+			expected: "\r▅\033[K\r█▁\033[K\n",
+		},
+		{
+			name:     "blank lines are ignored",
+			input:    "1\n\n  \n2",
+			size:     10,
+			expected: "\r▅\033[K\r▁█\033[K\n",
+		},
+		{
+			name:        "invalid number",
+			input:       "1\nabc\n",
+			size:        10,
+			expectError: true,
+			errorMsg:    "invalid number: abc",
+		},
+		{
+			name:        "vertical is not supported",
+			input:       "1\n",
+			size:        10,
+			config:      Config{Vertical: true},
+			expectError: true,
+			errorMsg:    "vertical graphs cannot be redrawn in place",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			_, err := Follow(strings.NewReader(tt.input), &out, tt.size, &tt.config)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if tt.errorMsg != "" && !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error message to contain '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if out.String() != tt.expected {
+				t.Errorf("got %q, want %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestFollowSkipped(t *testing.T) {
+	var out bytes.Buffer
+	skipped, err := Follow(strings.NewReader("1\nabc\n5 x\n"), &out, 10, &Config{SkipInvalid: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if skipped != 2 {
+		t.Errorf("got %d skipped tokens, want 2", skipped)
+	}
+	if expected := "\r▅\033[K\r▁█\033[K\n"; out.String() != expected {
+		t.Errorf("got %q, want %q", out.String(), expected)
+	}
+}
@@ -0,0 +1,140 @@
+package spark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// graphitePattern compiles a Graphite path pattern, where * matches any characters within a node,
+// ? a single one, [...] a character class and {a,b} any of the alternatives
+func graphitePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	depth := 0
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(`[^.]*`)
+		case '?':
+			b.WriteString(`[^.]`)
+		case '{':
+			depth++
+			b.WriteString("(?:")
+		case '}':
+			depth--
+			b.WriteString(")")
+		case ',':
+			if depth > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid graphite pattern, missing closing bracket: %s", pattern)
+			}
+			b.WriteString(pattern[i : i+end+1])
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil || depth != 0 {
+		return nil, fmt.Errorf("invalid graphite pattern: %s", pattern)
+	}
+	return re, nil
+}
+
+// parseGraphite reads Graphite plaintext lines (path value timestamp) from r and returns the values
+// of the paths matching config.Graphite, with one series per path, or per value of config.Tag
+// for tagged paths such as cpu.load;host=a.
+func parseGraphite(r io.Reader, config *Config) ([]Series, int, error) {
+	re, err := graphitePattern(config.Graphite)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var set seriesSet
+	skipped := 0
+
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadString('\n')
+		if line == "" && err == io.EOF {
+			break
+		}
+		if line == "" && err != nil {
+			return nil, skipped, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		tokens := strings.Fields(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		if len(tokens) != 3 && config.SkipInvalid {
+			skipped++
+			continue
+		}
+		if len(tokens) != 3 {
+			return nil, skipped, &ParseError{Line: lineNo, Column: 1, Token: line, Err: ErrInvalidPoint}
+		}
+
+		path, tags := graphiteTags(tokens[0])
+		if !re.MatchString(path) {
+			continue
+		}
+
+		// paths can hold the separators of other inputs, like ; for tags, so only spaces split the line
+		start := strings.Index(line, tokens[0]) + len(tokens[0])
+		start += strings.Index(line[start:], tokens[1])
+		value := field{token: tokens[1], column: utf8.RuneCountInString(line[:start]) + 1}
+
+		n, err := parseMetric(value.token)
+		if err != nil && config.SkipInvalid {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, skipped, &ParseError{Line: lineNo, Column: value.column, Token: value.token, Err: err}
+		}
+
+		// points without the tag to group by are left out, like unmatched paths
+		seriesLabel, found := tokens[0], true
+		if config.Tag != "" {
+			seriesLabel, found = tags[config.Tag]
+		}
+		if !found {
+			continue
+		}
+		set.add(seriesLabel, n)
+	}
+
+	if len(set.series) == 0 {
+		return nil, skipped, ErrNoData
+	}
+
+	sortSeries(set.series, config)
+
+	return set.series, skipped, nil
+}
+
+// graphiteTags splits a tagged path such as cpu.load;host=a;dc=eu into its path and tags
+func graphiteTags(s string) (string, map[string]string) {
+	parts := strings.Split(s, ";")
+	tags := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		if name, value, found := strings.Cut(part, "="); found {
+			tags[name] = value
+		}
+	}
+	return parts[0], tags
+}
@@ -0,0 +1,157 @@
+package spark
+
+import (
+	"strings"
+	"testing"
+)
+
+const metrics = `servers.web1.cpu 10 1700000000
+servers.web2.cpu 5 1700000000
+servers.db1.cpu 60 1700000000
+servers.web1.cpu 20.5 1700000060
+servers.web1.mem 700 1700000060
+load;host=web1;dc=eu 3 1700000000
+load;dc=us;host=web2 4 1700000000
+load;host=web1 5 1700000060
+`
+
+func TestParseGraphite(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		pattern     string
+		tag         string
+		skipInvalid bool
+		expected    []Series
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:     "exact path",
+			input:    metrics,
+			pattern:  "servers.web1.cpu",
+			expected: []Series{{Label: "servers.web1.cpu", Data: []int{10, 21}}},
+		},
+		{
+			name:    "wildcard within a node",
+			input:   metrics,
+			pattern: "servers.*.cpu",
+			expected: []Series{
+				{Label: "servers.web1.cpu", Data: []int{10, 21}},
+				{Label: "servers.web2.cpu", Data: []int{5}},
+				{Label: "servers.db1.cpu", Data: []int{60}},
+			},
+		},
+		{
+			name:     "fractional values",
+			input:    "ratio 0.25 1700000000\nratio 0.75 1700000060\n",
+			pattern:  "ratio",
+			expected: []Series{{Label: "ratio", Data: []int{0, 1}}},
+		},
+		{
+			name:        "wildcard does not cross nodes",
+			input:       metrics,
+			pattern:     "servers.*",
+			expectError: true,
+			errorMsg:    ErrNoData.Error(),
+		},
+		{
+			name:    "alternatives and character classes",
+			input:   metrics,
+			pattern: "servers.{web2,db[0-9]}.c?u",
+			expected: []Series{
+				{Label: "servers.web2.cpu", Data: []int{5}},
+				{Label: "servers.db1.cpu", Data: []int{60}},
+			},
+		},
+		{
+			name:    "tagged paths",
+			input:   metrics,
+			pattern: "load",
+			expected: []Series{
+				{Label: "load;host=web1;dc=eu", Data: []int{3}},
+				{Label: "load;dc=us;host=web2", Data: []int{4}},
+				{Label: "load;host=web1", Data: []int{5}},
+			},
+		},
+		{
+			name:    "grouped by tag",
+			input:   metrics,
+			pattern: "load",
+			tag:     "host",
+			expected: []Series{
+				{Label: "web1", Data: []int{3, 5}},
+				{Label: "web2", Data: []int{4}},
+			},
+		},
+		{
+			name:        "skip invalid values",
+			input:       "a 1 0\na nan 0\na 2 0\n",
+			pattern:     "a",
+			skipInvalid: true,
+			expected:    []Series{{Label: "a", Data: []int{1, 2}}},
+		},
+		{
+			name:     "points without the tag",
+			input:    "load;host=a 1 0\nload 2 0\n",
+			pattern:  "load",
+			tag:      "host",
+			expected: []Series{{Label: "a", Data: []int{1}}},
+		},
+		{
+			name:        "skip malformed lines",
+			input:       "a 1 0\na 1\na 2 0\n",
+			pattern:     "a",
+			skipInvalid: true,
+			expected:    []Series{{Label: "a", Data: []int{1, 2}}},
+		},
+		{
+			name:        "invalid value",
+			input:       "a 1 0\nload;host=x  abc 0\n",
+			pattern:     "load",
+			expectError: true,
+			errorMsg:    "line 2, column 14: invalid number: abc",
+		},
+		{
+			name:        "missing timestamp",
+			input:       "a 1\n",
+			pattern:     "a",
+			expectError: true,
+			errorMsg:    "line 1, column 1: invalid point: a 1",
+		},
+		{
+			name:        "invalid pattern",
+			input:       metrics,
+			pattern:     "servers.[ab",
+			expectError: true,
+			errorMsg:    "invalid graphite pattern, missing closing bracket: servers.[ab",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Graphite: tt.pattern, Tag: tt.tag, SkipInvalid: tt.skipInvalid}
+			actual, _, err := parseSeries(strings.NewReader(tt.input), config)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if !seriesEqual(actual, tt.expected) {
+				t.Errorf("got %v, want %v", actual, tt.expected)
+			}
+		})
+	}
+}
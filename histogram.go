@@ -0,0 +1,137 @@
+package spark
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// maxBins bounds the number of bins, as for buckets
+const maxBins = maxBuckets
+
+// Histogram counts data in bins of equal width spanning its range, and returns the count of every
+// bin with the edges between them, one more than the bins. Every bin holds the values from its
+// lower edge up to its upper edge excluded, except the last one which holds the maximum too. With
+// 0 bins, their number is picked by FreedmanDiaconisBins.
+func Histogram(data []int, bins int) ([]int, []float64, error) {
+	if bins < 0 || bins > maxBins {
+		return nil, nil, fmt.Errorf("invalid number of bins: %d", bins)
+	}
+	if len(data) == 0 {
+		return nil, nil, ErrNoData
+	}
+	if bins == 0 {
+		bins = FreedmanDiaconisBins(data)
+	}
+
+	low, high := float64(slices.Min(data)), float64(slices.Max(data))
+	width := (high - low) / float64(bins)
+
+	edges := make([]float64, bins+1)
+	for i := range edges {
+		edges[i] = low + float64(i)*width
+	}
+	edges[bins] = high
+
+	counts := make([]int, bins)
+	for _, n := range data {
+		i := bins - 1
+		if width > 0 {
+			i = min(bins-1, int((float64(n)-low)/width))
+		}
+		counts[i]++
+	}
+
+	return counts, edges, nil
+}
+
+// FreedmanDiaconisBins picks the number of bins of a histogram of data with the Freedman–Diaconis
+// rule, bins as wide as twice the interquartile range divided by the cube root of the number of
+// values. Data without any spread between its quartiles falls back to Sturges' rule. There are
+// never more bins than distinct integers in the range of data.
+func FreedmanDiaconisBins(data []int) int {
+	if len(data) == 0 {
+		return 1
+	}
+
+	spread := float64(slices.Max(data)) - float64(slices.Min(data))
+	if spread == 0 {
+		return 1
+	}
+
+	bins := math.Ceil(math.Log2(float64(len(data)))) + 1
+	if iqr := percentile(data, 75) - percentile(data, 25); iqr > 0 {
+		width := 2 * iqr / math.Cbrt(float64(len(data)))
+		bins = math.Ceil(spread / width)
+	}
+
+	return int(max(1, min(bins, spread+1, maxBins)))
+}
+
+// sparkHistogram draws the bin counts of data, with the stats of data itself
+func sparkHistogram(data []int, config *Config) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	counts, edges, err := Histogram(data, config.Bins)
+	if err != nil {
+		return "", err
+	}
+
+	g, err := layout(counts, nil, config)
+	if err != nil {
+		return "", err
+	}
+	if err := g.summary.setValues(data); err != nil {
+		return "", err
+	}
+	g.summary.edges = edges
+
+	return concatenateParts(g.ticks, g.styles, g.summary, g.separator, config), nil
+}
+
+// setValues replaces the stats of the bin counts by those of the values they count, in their order
+func (s *summary) setValues(data []int) error {
+	var err error
+	if s.minimum, s.maximum, s.sum, s.average, err = getStats(data); err != nil {
+		return err
+	}
+	if s.trend != nil {
+		trend, err := LinearTrend(data)
+		if err != nil {
+			return err
+		}
+		s.trend = &trend
+	}
+	return nil
+}
+
+// formatEdges formats bin edges rounded to 2 decimals, as in 1,2.5,4
+func formatEdges(edges []float64) string {
+	parts := make([]string, len(edges))
+	for i, edge := range edges {
+		parts[i] = formatFloat(math.Round(edge*100) / 100)
+	}
+	return strings.Join(parts, ",")
+}
+
+func validateHistogram(config *Config) error {
+	if config.Bins < 0 || config.Bins > maxBins {
+		return fmt.Errorf("invalid number of bins: %d", config.Bins)
+	}
+	if !config.Histogram {
+		if config.Bins > 0 {
+			return fmt.Errorf("bins need histogram mode")
+		}
+		return nil
+	}
+	if config.SeriesMode != "" || config.GroupBy > 0 || config.Prometheus != "" || config.Influx != "" || config.Graphite != "" {
+		return fmt.Errorf("histogram cannot be combined with several series")
+	}
+	if config.Bucket > 0 {
+		return fmt.Errorf("histogram cannot be combined with time buckets")
+	}
+	return nil
+}
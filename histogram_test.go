@@ -0,0 +1,122 @@
+package spark
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           []int
+		bins           int
+		expectedCounts []int
+		expectedEdges  []float64
+		expectError    bool
+		errorMsg       string
+	}{
+		{"equal bins", []int{1, 2, 2, 3, 9}, 4, []int{3, 1, 0, 1}, []float64{1, 3, 5, 7, 9}, false, ""},
+		{"maximum in last bin", []int{0, 10}, 2, []int{1, 1}, []float64{0, 5, 10}, false, ""},
+		{"fractional edges", []int{0, 1, 2}, 4, []int{1, 0, 1, 1}, []float64{0, 0.5, 1, 1.5, 2}, false, ""},
+		{"negative values", []int{-4, -2, 0, 4}, 2, []int{2, 2}, []float64{-4, 0, 4}, false, ""},
+		{"same values", []int{5, 5, 5}, 3, []int{0, 0, 3}, []float64{5, 5, 5, 5}, false, ""},
+		{"automatic bins", []int{1, 2, 2, 3, 3, 3, 4, 4, 5, 20}, 0, []int{3, 5, 1, 0, 0, 0, 0, 0, 0, 0, 1},
+			[]float64{1, 2.73, 4.45, 6.18, 7.91, 9.64, 11.36, 13.09, 14.82, 16.55, 18.27, 20}, false, ""},
+		{"no data", nil, 2, nil, nil, true, "no numeric data provided"},
+		{"negative bins", []int{1, 2}, -1, nil, nil, true, "invalid number of bins: -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts, edges, err := Histogram(tt.data, tt.bins)
+			if tt.expectError {
+				if err == nil || !contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing '%s', got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(counts, tt.expectedCounts) {
+				t.Errorf("got counts %v, want %v", counts, tt.expectedCounts)
+			}
+			if len(edges) != len(tt.expectedEdges) {
+				t.Fatalf("got edges %v, want %v", edges, tt.expectedEdges)
+			}
+			for i := range edges {
+				if diff := edges[i] - tt.expectedEdges[i]; diff > 0.01 || diff < -0.01 {
+					t.Errorf("got edges %v, want %v", edges, tt.expectedEdges)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestFreedmanDiaconisBins(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []int
+		expected int
+	}{
+		{"single value", []int{7}, 1},
+		{"same values", []int{3, 3, 3}, 1},
+		{"no spread between quartiles", []int{1, 5, 5, 5, 5, 5, 5, 9}, 4},
+		{"wide bins", []int{1, 2, 3}, 1},
+		{"interquartile range", []int{1, 2, 2, 3, 3, 3, 4, 4, 5, 20}, 11},
+		{"bounded by the integers in range", slices.Repeat([]int{0, 1}, 500), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := FreedmanDiaconisBins(tt.data); actual != tt.expected {
+				t.Errorf("FreedmanDiaconisBins(%v) = %d, want %d", tt.data, actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSparkHistogram(t *testing.T) {
+	config := &Config{Histogram: true, Bins: 4, ShowStats: true, ShowSum: true}
+	actual, err := Spark([]int{1, 2, 2, 3, 9}, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "█▃▁▃ (sum:17 min:1 max:9 avg:3.40 edges:1,3,5,7,9)"; actual != expected {
+		t.Errorf("got '%s', want '%s'", actual, expected)
+	}
+
+	config = &Config{Histogram: true, Bins: 3, Reverse: true}
+	if actual, err = Spark([]int{1, 1, 1, 2, 3}, config); err != nil || actual != "▁▁█" {
+		t.Errorf("got '%s' (%v), want '▁▁█'", actual, err)
+	}
+}
+
+func TestValidateHistogram(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		errorMsg string
+	}{
+		{"histogram", Config{Histogram: true, Bins: 10}, ""},
+		{"automatic bins", Config{Histogram: true}, ""},
+		{"negative bins", Config{Histogram: true, Bins: -2}, "invalid number of bins: -2"},
+		{"bins without histogram", Config{Bins: 10}, "bins need histogram mode"},
+		{"several series", Config{Histogram: true, SeriesMode: SeriesLines}, "histogram cannot be combined with several series"},
+		{"time buckets", Config{Histogram: true, Bucket: time.Minute}, "histogram cannot be combined with time buckets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHistogram(&tt.config)
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || err.Error() != tt.errorMsg) {
+				t.Errorf("expected error '%s', got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
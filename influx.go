@@ -0,0 +1,187 @@
+package spark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// influxPart is a part of a line protocol line, with the offset in bytes where it starts
+type influxPart struct {
+	text   string
+	offset int
+}
+
+// splitInflux splits s around every sep that is neither escaped nor within double quotes
+func splitInflux(s string, sep byte, offset int) []influxPart {
+	var parts []influxPart
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, influxPart{text: s[start:i], offset: offset + start})
+			start = i + 1
+		}
+	}
+	return append(parts, influxPart{text: s[start:], offset: offset + start})
+}
+
+// unescapeInflux removes the backslashes escaping commas, equal signs, spaces and quotes
+func unescapeInflux(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`,= "\`, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// influxSelector splits the measurement.field selection at its last dot, since measurements are
+// often dotted (http.requests.count) while fields rarely are, the measurement being optional
+func influxSelector(s string) (string, string) {
+	if i := strings.LastIndexByte(s, '.'); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return "", s
+}
+
+// parseInflux reads InfluxDB line protocol from r and returns the values of the field selected by
+// config.Influx, as measurement.field or field for any measurement. There is one series per tag
+// set, or per value of config.Tag if given. Integer fields may end with i or u, and fields that
+// are not numbers (strings and booleans) are invalid.
+func parseInflux(r io.Reader, config *Config) ([]Series, int, error) {
+	measurementName, fieldName := influxSelector(config.Influx)
+
+	var set seriesSet
+	skipped := 0
+
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadString('\n')
+		if line == "" && err == io.EOF {
+			break
+		}
+		if line == "" && err != nil {
+			return nil, skipped, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := splitInflux(line, ' ', 0)
+		if (len(parts) < 2 || len(parts) > 3 || parts[0].text == "") && config.SkipInvalid {
+			skipped++
+			continue
+		}
+		if len(parts) < 2 || len(parts) > 3 || parts[0].text == "" {
+			return nil, skipped, &ParseError{Line: lineNo, Column: 1, Token: line, Err: ErrInvalidPoint}
+		}
+
+		key := splitInflux(parts[0].text, ',', 0)
+		measurement := unescapeInflux(key[0].text)
+		if measurementName != "" && measurement != measurementName {
+			continue
+		}
+
+		tags, err := influxTags(key[1:])
+		if err != nil && config.SkipInvalid {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, skipped, &ParseError{Line: lineNo, Column: 1, Token: line, Err: err}
+		}
+
+		value, found := influxField(parts[1], fieldName)
+		if !found {
+			continue
+		}
+
+		n, err := parseMetric(strings.TrimSuffix(strings.TrimSuffix(value.token, "i"), "u"))
+		if err != nil && config.SkipInvalid {
+			skipped++
+			continue
+		}
+		if err != nil {
+			value.column = utf8.RuneCountInString(line[:value.column]) + 1
+			return nil, skipped, &ParseError{Line: lineNo, Column: value.column, Token: value.token, Err: err}
+		}
+
+		// points without the tag to group by are left out, like other measurements
+		seriesLabel, found := influxLabel(measurement, tags, measurementName == "", config.Tag)
+		if !found {
+			continue
+		}
+		set.add(seriesLabel, n)
+	}
+
+	if len(set.series) == 0 {
+		return nil, skipped, ErrNoData
+	}
+
+	sortSeries(set.series, config)
+
+	return set.series, skipped, nil
+}
+
+// influxTags parses the name=value tags of a point
+func influxTags(parts []influxPart) ([]label, error) {
+	tags := make([]label, 0, len(parts))
+	for _, tag := range parts {
+		name, value, found := strings.Cut(tag.text, "=")
+		if !found || name == "" {
+			return nil, ErrInvalidPoint
+		}
+		tags = append(tags, label{name: unescapeInflux(name), value: unescapeInflux(value)})
+	}
+	return tags, nil
+}
+
+// influxField finds the value of name among the fields, with its offset in bytes as column
+func influxField(fields influxPart, name string) (field, bool) {
+	for _, f := range splitInflux(fields.text, ',', fields.offset) {
+		key, value, found := strings.Cut(f.text, "=")
+		if found && unescapeInflux(key) == name {
+			return field{token: value, column: f.offset + len(key) + 1}, true
+		}
+	}
+	return field{}, false
+}
+
+// influxLabel labels a point by the value of tag if given, or else by its tag set, prefixed by
+// its measurement when the selection does not give it. A point without the tag has no label.
+func influxLabel(measurement string, tags []label, withMeasurement bool, tag string) (string, bool) {
+	if tag != "" {
+		if i := slices.IndexFunc(tags, func(l label) bool { return l.name == tag }); i >= 0 {
+			return tags[i].value, true
+		}
+		return "", false
+	}
+
+	slices.SortFunc(tags, func(a, b label) int {
+		return strings.Compare(a.name, b.name)
+	})
+	parts := make([]string, 0, len(tags)+1)
+	if withMeasurement {
+		parts = append(parts, measurement)
+	}
+	for _, t := range tags {
+		parts = append(parts, fmt.Sprintf("%s=%s", t.name, t.value))
+	}
+	return strings.Join(parts, ","), true
+}
@@ -0,0 +1,154 @@
+package spark
+
+import (
+	"strings"
+	"testing"
+)
+
+const points = `# collected by telegraf
+cpu,host=a,region=eu usage=10i,idle=90 1700000000000000000
+cpu,host=b,region=eu usage=20.5,idle=79.5
+mem,host=a used=3u
+cpu,region=eu,host=a usage=30i,idle=70
+weather,city=New\ York temp=-3,note="a \"cold\", windy day"
+`
+
+func TestParseInflux(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		field       string
+		tag         string
+		skipInvalid bool
+		expected    []Series
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:  "measurement and field",
+			input: points,
+			field: "cpu.usage",
+			expected: []Series{
+				{Label: "host=a,region=eu", Data: []int{10, 30}},
+				{Label: "host=b,region=eu", Data: []int{21}},
+			},
+		},
+		{
+			name:  "field of any measurement",
+			input: points,
+			field: "idle",
+			expected: []Series{
+				{Label: "cpu,host=a,region=eu", Data: []int{90, 70}},
+				{Label: "cpu,host=b,region=eu", Data: []int{80}},
+			},
+		},
+		{
+			name:     "grouped by tag",
+			input:    points,
+			field:    "usage",
+			tag:      "region",
+			expected: []Series{{Label: "eu", Data: []int{10, 21, 30}}},
+		},
+		{
+			name:     "fractional fields",
+			input:    "load value=0.25\nload value=0.75\nload value=-1.5\n",
+			field:    "load.value",
+			expected: []Series{{Label: "", Data: []int{0, 1, -2}}},
+		},
+		{
+			name:     "dotted measurement",
+			input:    "http.requests.count,code=200 value=4\nhttp.requests value=9\nhttp.requests.count,code=200 value=6\n",
+			field:    "http.requests.count.value",
+			expected: []Series{{Label: "code=200", Data: []int{4, 6}}},
+		},
+		{
+			name:     "unsigned integer",
+			input:    points,
+			field:    "mem.used",
+			expected: []Series{{Label: "host=a", Data: []int{3}}},
+		},
+		{
+			name:     "escaped names and quoted strings",
+			input:    points,
+			field:    "weather.temp",
+			tag:      "city",
+			expected: []Series{{Label: "New York", Data: []int{-3}}},
+		},
+		{
+			name:        "skip booleans",
+			input:       "up ok=t\nup ok=1\n",
+			field:       "ok",
+			skipInvalid: true,
+			expected:    []Series{{Label: "up", Data: []int{1}}},
+		},
+		{
+			name:     "points without the tag",
+			input:    "cpu,region=eu usage=1\ncpu usage=2\ncpu,region=us usage=3\n",
+			field:    "usage",
+			tag:      "region",
+			expected: []Series{{Label: "eu", Data: []int{1}}, {Label: "us", Data: []int{3}}},
+		},
+		{
+			name:        "skip malformed points",
+			input:       "cpu usage=1\ncpu,host=a\ncpu,host usage=5\ncpu usage=2\n",
+			field:       "usage",
+			skipInvalid: true,
+			expected:    []Series{{Label: "cpu", Data: []int{1, 2}}},
+		},
+		{
+			name:        "string field",
+			input:       points,
+			field:       "note",
+			expectError: true,
+			errorMsg:    `line 6, column 37: invalid number: "a \"cold\", windy day"`,
+		},
+		{
+			name:        "missing fields",
+			input:       "cpu,host=a\n",
+			field:       "usage",
+			expectError: true,
+			errorMsg:    "line 1, column 1: invalid point: cpu,host=a",
+		},
+		{
+			name:        "invalid tag",
+			input:       "cpu,host usage=1\n",
+			field:       "usage",
+			expectError: true,
+			errorMsg:    "line 1, column 1: invalid point: cpu,host usage=1",
+		},
+		{
+			name:        "no matching field",
+			input:       points,
+			field:       "cpu.load",
+			expectError: true,
+			errorMsg:    ErrNoData.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Influx: tt.field, Tag: tt.tag, SkipInvalid: tt.skipInvalid}
+			actual, _, err := parseSeries(strings.NewReader(tt.input), config)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if !seriesEqual(actual, tt.expected) {
+				t.Errorf("got %v, want %v", actual, tt.expected)
+			}
+		})
+	}
+}
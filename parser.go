@@ -0,0 +1,197 @@
+package spark
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parser reads numbers one at a time from a reader, so lines can be of any length and values
+// are available as soon as they are terminated by a separator.
+type Parser struct {
+	// SkipInvalid makes Next skip tokens that are not valid numbers instead of failing.
+	SkipInvalid bool
+	// Match, when set, extracts the tokens of every line with this regular expression (or its first
+	// capture group, or the one named value) instead of splitting lines around separators. Lines
+	// without a match are ignored.
+	Match *regexp.Regexp
+
+	r       *bufio.Reader
+	line    int
+	column  int
+	skipped int
+	pending []field
+}
+
+func NewParser(r io.Reader) *Parser {
+	return &Parser{r: bufio.NewReader(r), line: 1}
+}
+
+// Next returns the next number in the input, or io.EOF once the input is exhausted.
+func (p *Parser) Next() (int, error) {
+	for {
+		token, line, column, err := p.nextToken()
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := parseNumber(token)
+		if err == nil {
+			return n, nil
+		}
+		if !p.SkipInvalid {
+			return 0, &ParseError{Line: line, Column: column, Token: token, Err: err}
+		}
+		p.skipped++
+	}
+}
+
+// Skipped returns how many invalid tokens were skipped so far.
+func (p *Parser) Skipped() int {
+	return p.skipped
+}
+
+func (p *Parser) nextToken() (string, int, int, error) {
+	if p.Match != nil {
+		return p.nextMatch()
+	}
+
+	var token strings.Builder
+	var line, column int
+
+	for {
+		r, _, err := p.r.ReadRune()
+		if err != nil {
+			if err == io.EOF && token.Len() > 0 {
+				return token.String(), line, column, nil
+			}
+			return "", 0, 0, err
+		}
+		p.column++
+
+		if isSeparator(r) {
+			if r == '\n' {
+				p.line++
+				p.column = 0
+			}
+			if token.Len() > 0 {
+				return token.String(), line, column, nil
+			}
+			continue
+		}
+
+		if token.Len() == 0 {
+			line, column = p.line, p.column
+		}
+		token.WriteRune(r)
+	}
+}
+
+func (p *Parser) nextMatch() (string, int, int, error) {
+	for len(p.pending) == 0 {
+		line, err := p.r.ReadString('\n')
+		if line == "" && err != nil {
+			return "", 0, 0, err
+		}
+		p.pending = extractFields(strings.TrimRight(line, "\r\n"), p.Match)
+		p.line++
+	}
+
+	f := p.pending[0]
+	p.pending = p.pending[1:]
+	return f.token, p.line - 1, f.column, nil
+}
+
+func newParser(r io.Reader, config *Config) (*Parser, error) {
+	re, err := config.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	parser := NewParser(r)
+	parser.SkipInvalid = config.SkipInvalid
+	parser.Match = re
+	return parser, nil
+}
+
+// ParseReader parses every number in r.
+func ParseReader(r io.Reader) ([]int, error) {
+	data, _, err := parseReader(r, &Config{})
+	return data, err
+}
+
+func parseReader(r io.Reader, config *Config) ([]int, int, error) {
+	parser, err := newParser(r, config)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var data []int
+	for {
+		n, err := parser.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, parser.Skipped(), err
+		}
+		data = append(data, n)
+	}
+
+	if len(data) < 1 {
+		return nil, parser.Skipped(), ErrNoData
+	}
+
+	return data, parser.Skipped(), nil
+}
+func parseNumber(token string) (int, error) {
+	// integers are parsed exactly, floats cannot represent every int64
+	if i, err := strconv.ParseInt(token, 10, 64); err == nil {
+		return int(i), nil
+	}
+
+	f, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, ErrInvalidNumber
+	}
+
+	return floatToInt(f)
+}
+
+// parseMetric is parseNumber for metric samples, which are floats by nature (rates, ratios,
+// seconds) and are rounded to the nearest integer rather than truncated
+func parseMetric(token string) (int, error) {
+	if i, err := strconv.ParseInt(token, 10, 64); err == nil {
+		return int(i), nil
+	}
+
+	f, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, ErrInvalidNumber
+	}
+
+	return floatToInt(math.Round(f))
+}
+
+// floatToInt truncates f to an int, failing if it is not finite or out of range
+func floatToInt(f float64) (int, error) {
+	// check bounds
+	if math.IsInf(f, 0) {
+		return 0, ErrInfinite
+	}
+	if math.IsNaN(f) {
+		return 0, ErrNaN
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which is already out of range
+	if f >= math.MaxInt64 {
+		return 0, ErrTooLarge
+	}
+	if f < math.MinInt64 {
+		return 0, ErrTooSmall
+	}
+
+	return int(f), nil
+}
@@ -0,0 +1,325 @@
+package spark
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    []int
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:     "single line",
+			input:    "1 2 3",
+			expected: []int{1, 2, 3},
+		},
+		{
+			name:     "multiple lines and separators",
+			input:    "1,2|3\n4;5\r\n6\n",
+			expected: []int{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name:     "floats and negative numbers",
+			input:    "-1.5 2.9 1e2",
+			expected: []int{-1, 2, 100},
+		},
+		{
+			name:        "empty input",
+			input:       "",
+			expectError: true,
+			errorMsg:    "no numeric data provided - specify numbers as arguments or pipe data via stdin",
+		},
+		{
+			name:        "only separators",
+			input:       " ,\n|; \n",
+			expectError: true,
+			errorMsg:    "no numeric data provided - specify numbers as arguments or pipe data via stdin",
+		},
+		{
+			name:        "invalid number position",
+			input:       "1 2\n3 abc 5",
+			expectError: true,
+			errorMsg:    "line 2, column 3: invalid number: abc",
+		},
+		{
+			name:        "column counts characters not bytes",
+			input:       "1 ▁ 2",
+			expectError: true,
+			errorMsg:    "line 1, column 3: invalid number: ▁",
+		},
+		{
+			name:        "infinite number position",
+			input:       "\n\n  inf",
+			expectError: true,
+			errorMsg:    "line 3, column 3: infinite numbers not supported: inf",
+		},
+		{
+			name:        "invalid number at end of input",
+			input:       "1 2x",
+			expectError: true,
+			errorMsg:    "line 1, column 3: invalid number: 2x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseReader(bytes.NewBufferString(tt.input))
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if !sliceEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseReaderLongLine(t *testing.T) {
+	// longer than the 64KB limit of bufio.Scanner
+	input := strings.Repeat("7 ", 100000)
+
+	result, err := ParseReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 100000 {
+		t.Errorf("got %d numbers, want %d", len(result), 100000)
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	parser := NewParser(strings.NewReader("1\n  nan"))
+
+	if n, err := parser.Next(); err != nil || n != 1 {
+		t.Fatalf("got %d, %v, want 1, nil", n, err)
+	}
+
+	_, err := parser.Next()
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a *ParseError, got %v", err)
+	}
+	if parseErr.Line != 2 || parseErr.Column != 3 || parseErr.Token != "nan" {
+		t.Errorf("got line %d, column %d, token %s, want line 2, column 3, token nan", parseErr.Line, parseErr.Column, parseErr.Token)
+	}
+	if !errors.Is(err, ErrNaN) {
+		t.Errorf("expected error to wrap ErrNaN, got %v", err)
+	}
+
+	if _, err = parser.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestSkipInvalid(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		stdinData       string
+		expected        []int
+		expectedSkipped int
+		expectError     bool
+	}{
+		{
+			name:            "args with invalid tokens",
+			args:            []string{"1", "abc", "inf", "3"},
+			expected:        []int{1, 3},
+			expectedSkipped: 2,
+		},
+		{
+			name:            "stdin with header and NaN",
+			stdinData:       "latency\n12\nNaN\n15 n/a 9\n",
+			expected:        []int{12, 15, 9},
+			expectedSkipped: 3,
+		},
+		{
+			name:            "nothing valid",
+			args:            []string{"a", "b"},
+			expectedSkipped: 2,
+			expectError:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []int
+			var skipped int
+			var err error
+			config := &Config{SkipInvalid: true}
+			if tt.stdinData != "" {
+				result, skipped, err = parseReader(strings.NewReader(tt.stdinData), config)
+			} else {
+				result, skipped, err = parseSource(tt.args, config)
+			}
+
+			if skipped != tt.expectedSkipped {
+				t.Errorf("got %d skipped, want %d", skipped, tt.expectedSkipped)
+			}
+
+			if tt.expectError {
+				if !errors.Is(err, ErrNoData) {
+					t.Errorf("expected ErrNoData, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if !sliceEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		match           string
+		field           string
+		skipInvalid     bool
+		expected        []int
+		expectedSkipped int
+		expectError     bool
+		errorMsg        string
+	}{
+		{
+			name:     "whole match",
+			input:    "cpu 12% mem 40%\nidle\ncpu 7%",
+			match:    `\d+`,
+			expected: []int{12, 40, 7},
+		},
+		{
+			name:     "capture group",
+			input:    "GET /a took 120ms\nGET /b took 85ms\nstarting\n",
+			match:    `took (\d+)ms`,
+			expected: []int{120, 85},
+		},
+		{
+			name:     "first capture group",
+			input:    "took 12ms\ntook 3s\n",
+			match:    `took (\d+)(ms|s)`,
+			expected: []int{12, 3},
+		},
+		{
+			name:     "capture group named value",
+			input:    "load 1/5\nload 3/2\n",
+			match:    `(\d+)/(?P<value>\d+)`,
+			expected: []int{5, 2},
+		},
+		{
+			name:     "logfmt field",
+			input:    "req=/api latency=123ms status=200\nreq=/x status=500 latency=9.5\nreq=/y status=200\n",
+			field:    "latency",
+			expected: []int{123, 9},
+		},
+		{
+			name:     "logfmt field with quotes and negative value",
+			input:    `a=1 delta="-4" b=2` + "\n",
+			field:    "delta",
+			expected: []int{-4},
+		},
+		{
+			name:     "logfmt field does not match key suffix",
+			input:    "total_latency=500 latency=5\n",
+			field:    "latency",
+			expected: []int{5},
+		},
+		{
+			name:        "invalid capture position",
+			input:       "v=1\nv=x\n",
+			match:       `v=(\w+)`,
+			expectError: true,
+			errorMsg:    "line 2, column 3: invalid number: x",
+		},
+		{
+			name:            "invalid capture skipped",
+			input:           "v=1\nv=x\n",
+			match:           `v=(\w+)`,
+			skipInvalid:     true,
+			expected:        []int{1},
+			expectedSkipped: 1,
+		},
+		{
+			name:        "no match at all",
+			input:       "nothing here\n",
+			field:       "latency",
+			expectError: true,
+			errorMsg:    "no numeric data provided - specify numbers as arguments or pipe data via stdin",
+		},
+		{
+			name:        "invalid pattern",
+			input:       "1\n",
+			match:       `(`,
+			expectError: true,
+			errorMsg:    "invalid match pattern: error parsing regexp: missing closing ): `(`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Match: tt.match, Field: tt.field, SkipInvalid: tt.skipInvalid}
+			result, skipped, err := parseReader(strings.NewReader(tt.input), config)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if skipped != tt.expectedSkipped {
+				t.Errorf("got %d skipped, want %d", skipped, tt.expectedSkipped)
+			}
+
+			if !sliceEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+
+			// arguments are extracted the same way, one line per argument
+			argsResult, _, err := parseSource(strings.Split(strings.TrimSuffix(tt.input, "\n"), "\n"), config)
+			if err != nil {
+				t.Errorf("unexpected error from args: %v", err)
+				return
+			}
+			if !sliceEqual(argsResult, tt.expected) {
+				t.Errorf("got %v from args, want %v", argsResult, tt.expected)
+			}
+		})
+	}
+}
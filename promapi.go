@@ -0,0 +1,134 @@
+package spark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// queryRangeResponse is the body of a /api/v1/query_range response
+type queryRangeResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]any          `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryRange evaluates query from start to end every step with the query_range endpoint of the
+// Prometheus compatible HTTP API at baseURL, and returns one series per result labeled with its
+// metric name and labels. Invalid values such as NaN stop it, unless config.SkipInvalid is set.
+func QueryRange(ctx context.Context, client *http.Client, baseURL, query string, start, end time.Time, step time.Duration, config *Config) ([]Series, int, error) {
+	if step <= 0 {
+		return nil, 0, fmt.Errorf("step must be positive: %s", step)
+	}
+	if !end.After(start) {
+		return nil, 0, fmt.Errorf("range must end after it starts")
+	}
+
+	endpoint, err := url.JoinPath(baseURL, "/api/v1/query_range")
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid url: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", formatUnix(start))
+	params.Set("end", formatUnix(end))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid url: %w", err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query failed: %w", err)
+	}
+	defer response.Body.Close()
+
+	// errors come with a JSON body too, which says more than the status code
+	var body queryRangeResponse
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		if response.StatusCode != http.StatusOK {
+			return nil, 0, fmt.Errorf("query failed: %s", response.Status)
+		}
+		return nil, 0, fmt.Errorf("query failed, invalid response: %w", err)
+	}
+	if body.Status != "success" {
+		return nil, 0, fmt.Errorf("query failed: %s: %s", body.ErrorType, body.Error)
+	}
+	if body.Data.ResultType != "matrix" {
+		return nil, 0, fmt.Errorf("query failed: expected a matrix result, got %s", body.Data.ResultType)
+	}
+
+	series := make([]Series, 0, len(body.Data.Result))
+	skipped := 0
+	for _, result := range body.Data.Result {
+		s := Series{Label: metricLabel(result.Metric)}
+		for _, point := range result.Values {
+			value, ok := point[1].(string)
+			if !ok {
+				return nil, skipped, fmt.Errorf("%s: %w: %v", s.Label, ErrInvalidNumber, point[1])
+			}
+
+			n, err := parseMetric(value)
+			if err != nil && config.SkipInvalid {
+				skipped++
+				continue
+			}
+			if err != nil {
+				return nil, skipped, fmt.Errorf("%s: %w: %s", s.Label, err, value)
+			}
+			s.Data = append(s.Data, n)
+		}
+		if len(s.Data) > 0 {
+			series = append(series, s)
+		}
+	}
+
+	if len(series) == 0 {
+		return nil, skipped, fmt.Errorf("query returned no data: %s", query)
+	}
+
+	sortSeries(series, config)
+
+	return series, skipped, nil
+}
+
+// metricLabel formats a metric as in name{label="value"}, like the Prometheus text format
+func metricLabel(metric map[string]string) string {
+	labels := make([]label, 0, len(metric))
+	for name, value := range metric {
+		if name != "__name__" {
+			labels = append(labels, label{name: name, value: value})
+		}
+	}
+
+	if s := metric["__name__"] + formatLabels(labels); s != "" {
+		return s
+	}
+	return "{}"
+}
+
+func formatUnix(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
+
+// DefaultStep divides duration into size steps, of at least a second
+func DefaultStep(duration time.Duration, size int) time.Duration {
+	if size < 1 {
+		return duration
+	}
+	return max(time.Second, (duration / time.Duration(size)).Truncate(time.Second))
+}
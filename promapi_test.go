@@ -0,0 +1,129 @@
+package spark
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQueryRange(t *testing.T) {
+	var params map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prometheus/api/v1/query_range" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		params = map[string]string{"query": query.Get("query"), "start": query.Get("start"), "end": query.Get("end"), "step": query.Get("step")}
+
+		switch query.Get("query") {
+		case "fail":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error at char 1"}`))
+		case "vector":
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+		case "text":
+			_, _ = w.Write([]byte("too many requests"))
+		case "empty":
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+		case "fractional":
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"job":"api"},"values":[[1700000000,"0.02"],[1700000060,"0.5"],[1700000120,"1.4"],[1700000180,"-2.6"]]}]}}`))
+		case "nan":
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{},"values":[[1700000000,"1"],[1700000060,"NaN"],[1700000120,"3"]]}]}}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"__name__":"up","job":"db"},"values":[[1700000000,"0"],[1700000060,"1"]]},
+				{"metric":{"job":"api","instance":"a:9100"},"values":[[1700000000,"2.5"],[1700000060,"7"],[1700000120,"9"]]}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	start := time.Unix(1700000000, 0)
+	end := start.Add(2 * time.Minute)
+	client := server.Client()
+
+	series, _, err := QueryRange(context.Background(), client, server.URL+"/prometheus", "rate(x[5m])", start, end, time.Minute, &Config{SortSeries: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Series{
+		{Label: `up{job="db"}`, Data: []int{0, 1}},
+		{Label: `{instance="a:9100",job="api"}`, Data: []int{3, 7, 9}},
+	}
+	if !seriesEqual(series, expected) {
+		t.Errorf("got %v, want %v", series, expected)
+	}
+	if want := map[string]string{"query": "rate(x[5m])", "start": "1700000000", "end": "1700000120", "step": "60"}; len(params) != len(want) ||
+		params["query"] != want["query"] || params["start"] != want["start"] || params["end"] != want["end"] || params["step"] != want["step"] {
+		t.Errorf("got parameters %v, want %v", params, want)
+	}
+
+	// rates and ratios are floats, which are rounded rather than truncated towards 0
+	series, _, err = QueryRange(context.Background(), client, server.URL+"/prometheus", "fractional", start, end, time.Minute, &Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !seriesEqual(series, []Series{{Label: `{job="api"}`, Data: []int{0, 1, 1, -3}}}) {
+		t.Errorf("got %v, want {job=\"api\"} [0 1 1 -3]", series)
+	}
+
+	series, skipped, err := QueryRange(context.Background(), client, server.URL+"/prometheus", "nan", start, end, time.Minute, &Config{SkipInvalid: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !seriesEqual(series, []Series{{Label: "{}", Data: []int{1, 3}}}) || skipped != 1 {
+		t.Errorf("got %v with %d skipped, want {} [1 3] with 1 skipped", series, skipped)
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		query    string
+		step     time.Duration
+		errorMsg string
+	}{
+		{"api error", server.URL + "/prometheus", "fail", time.Minute, "query failed: bad_data: parse error at char 1"},
+		{"not a matrix", server.URL + "/prometheus", "vector", time.Minute, "query failed: expected a matrix result, got vector"},
+		{"no series", server.URL + "/prometheus", "empty", time.Minute, "query returned no data: empty"},
+		{"invalid value", server.URL + "/prometheus", "nan", time.Minute, "{}: NaN (not a number) not supported: NaN"},
+		{"not found", server.URL, "up", time.Minute, "query failed: 404 Not Found"},
+		{"not json", server.URL + "/prometheus", "text", time.Minute, "query failed, invalid response: invalid character 'o' in literal true (expecting 'r')"},
+		{"invalid step", server.URL, "up", 0, "step must be positive: 0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := QueryRange(context.Background(), client, tt.url, tt.query, start, end, tt.step, &Config{})
+			if err == nil {
+				t.Errorf("expected error but got none")
+				return
+			}
+			if err.Error() != tt.errorMsg {
+				t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestDefaultStep(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		size     int
+		expected time.Duration
+	}{
+		{time.Hour, 40, 90 * time.Second},
+		{time.Hour, 7, 514 * time.Second},
+		{10 * time.Second, 40, time.Second},
+		{time.Hour, 0, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if actual := DefaultStep(tt.duration, tt.size); actual != tt.expected {
+			t.Errorf("DefaultStep(%s, %d) = %s, want %s", tt.duration, tt.size, actual, tt.expected)
+		}
+	}
+}
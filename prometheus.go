@@ -0,0 +1,314 @@
+package spark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+// LabelMatcher matches the value of a label, which is empty when the label is missing
+type LabelMatcher struct {
+	Name  string
+	Type  string
+	Value string
+	re    *regexp.Regexp
+}
+
+func (m *LabelMatcher) matches(value string) bool {
+	switch m.Type {
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+	return value == m.Value
+}
+
+// Selector selects samples by metric name and labels, as in http_requests_total{code=~"5.."}
+type Selector struct {
+	Name     string
+	Matchers []LabelMatcher
+}
+
+// ParseSelector parses a Prometheus instant vector selector. Regular expressions are anchored at
+// both ends, like in Prometheus.
+func ParseSelector(s string) (*Selector, error) {
+	name, labels, hasLabels := strings.Cut(strings.TrimSpace(s), "{")
+	selector := &Selector{Name: strings.TrimSpace(name)}
+
+	if hasLabels {
+		if !strings.HasSuffix(labels, "}") {
+			return nil, fmt.Errorf("invalid selector, missing closing brace: %s", s)
+		}
+		pairs, err := parseLabels(strings.TrimSuffix(labels, "}"), true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector, %v: %s", err, s)
+		}
+		for _, pair := range pairs {
+			matcher := LabelMatcher{Name: pair.name, Type: pair.op, Value: pair.value}
+			if matcher.Type == MatchRegexp || matcher.Type == MatchNotRegexp {
+				if matcher.re, err = regexp.Compile("^(?:" + pair.value + ")$"); err != nil {
+					return nil, fmt.Errorf("invalid selector, %w: %s", err, s)
+				}
+			}
+			selector.Matchers = append(selector.Matchers, matcher)
+		}
+	}
+
+	if selector.Name == "" && len(selector.Matchers) == 0 {
+		return nil, fmt.Errorf("invalid selector, no metric name or labels: %s", s)
+	}
+	return selector, nil
+}
+
+func (s *Selector) matches(name string, labels []label) bool {
+	if s.Name != "" && s.Name != name {
+		return false
+	}
+	for _, matcher := range s.Matchers {
+		value := ""
+		if i := slices.IndexFunc(labels, func(l label) bool { return l.name == matcher.Name }); i >= 0 {
+			value = labels[i].value
+		}
+		if !matcher.matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// label is a label of a sample, or a matcher of a selector with its operator
+type label struct {
+	name  string
+	op    string
+	value string
+}
+
+// parseLabels parses comma separated name="value" pairs, with any matcher operator if allowed
+func parseLabels(s string, matchers bool) ([]label, error) {
+	var labels []label
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return labels, nil
+		}
+
+		end := strings.IndexAny(s, "=!~")
+		if end <= 0 {
+			return nil, fmt.Errorf("expected label name at %q", s)
+		}
+		l := label{name: strings.TrimSpace(s[:end])}
+		s = s[end:]
+
+		for _, op := range []string{MatchRegexp, MatchNotRegexp, MatchNotEqual, MatchEqual} {
+			if strings.HasPrefix(s, op) {
+				l.op, s = op, strings.TrimLeft(s[len(op):], " \t")
+				break
+			}
+		}
+		if l.op == "" || (!matchers && l.op != MatchEqual) {
+			return nil, fmt.Errorf("invalid operator for label %s", l.name)
+		}
+
+		value, rest, err := unquoteLabel(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w for label %s", err, l.name)
+		}
+		l.value = value
+		labels = append(labels, l)
+
+		rest = strings.TrimLeft(rest, " \t")
+		if rest != "" && rest[0] != ',' {
+			return nil, fmt.Errorf("expected comma after label %s", l.name)
+		}
+		s = strings.TrimPrefix(rest, ",")
+	}
+}
+
+// unquoteLabel reads a double quoted label value at the start of s, and returns it with the rest of s
+func unquoteLabel(s string) (string, string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", "", fmt.Errorf("expected quoted value")
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), s[i+1:], nil
+		case '\\':
+			if i+1 == len(s) {
+				return "", "", fmt.Errorf("unterminated value")
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", fmt.Errorf("unterminated value")
+}
+
+// formatLabels formats labels sorted by name, as in {code="200",method="GET"}
+func formatLabels(labels []label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	sorted := slices.Clone(labels)
+	slices.SortFunc(sorted, func(a, b label) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	parts := make([]string, len(sorted))
+	for i, l := range sorted {
+		parts[i] = fmt.Sprintf("%s=%q", l.name, l.value)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// parsePrometheus reads samples in the Prometheus text exposition format from r, and returns one
+// series per metric and label set selected by config.Prometheus. Samples of repeated scrapes are
+// appended to their series, in the order they are read.
+func parsePrometheus(r io.Reader, config *Config) ([]Series, int, error) {
+	selector, err := ParseSelector(config.Prometheus)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var set seriesSet
+	skipped := 0
+
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadString('\n')
+		if line == "" && err == io.EOF {
+			break
+		}
+		if line == "" && err != nil {
+			return nil, skipped, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, labels, value, err := parseSample(line)
+		// a malformed line of another metric is none of our business
+		if err != nil && selector.Name != "" && sampleName(line) != selector.Name {
+			continue
+		}
+		if err != nil && config.SkipInvalid {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, skipped, &ParseError{Line: lineNo, Column: 1, Token: line, Err: err}
+		}
+		if !selector.matches(name, labels) {
+			continue
+		}
+
+		n, err := parseMetric(value.token)
+		if err != nil && config.SkipInvalid {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, skipped, &ParseError{Line: lineNo, Column: value.column, Token: value.token, Err: err}
+		}
+
+		// the name is left out when the selector gives it, unless there is nothing else
+		seriesLabel := name + formatLabels(labels)
+		if selector.Name != "" && len(labels) > 0 {
+			seriesLabel = formatLabels(labels)
+		}
+		set.add(seriesLabel, n)
+	}
+
+	if len(set.series) == 0 {
+		return nil, skipped, ErrNoData
+	}
+
+	sortSeries(set.series, config)
+
+	return set.series, skipped, nil
+}
+
+// sampleName returns the metric name at the start of a sample line
+func sampleName(line string) string {
+	if end := strings.IndexAny(line, "{ \t"); end >= 0 {
+		return line[:end]
+	}
+	return line
+}
+
+// parseSample splits a sample line into its metric name, labels and value, ignoring the timestamp
+// and any OpenMetrics exemplar after a #
+func parseSample(line string) (string, []label, field, error) {
+	name := sampleName(line)
+	if name == "" || name == line {
+		return "", nil, field{}, ErrInvalidSample
+	}
+	rest := line[len(name):]
+
+	var labels []label
+	if strings.HasPrefix(rest, "{") {
+		closing := closingBrace(rest)
+		if closing < 0 {
+			return "", nil, field{}, ErrInvalidSample
+		}
+
+		var err error
+		if labels, err = parseLabels(rest[1:closing], false); err != nil {
+			return "", nil, field{}, fmt.Errorf("%w, %v", ErrInvalidSample, err)
+		}
+		rest = rest[closing+1:]
+	}
+
+	// an OpenMetrics exemplar follows the value and timestamp after a #
+	sample, _, _ := strings.Cut(rest, "#")
+	values := fields(sample)
+	if len(values) == 0 || len(values) > 2 {
+		return "", nil, field{}, ErrInvalidSample
+	}
+
+	value := values[0]
+	// columns count characters, as for every other input
+	value.column += len([]rune(line[:len(line)-len(rest)]))
+	return name, labels, value, nil
+}
+
+// closingBrace returns the index of the brace closing the labels at the start of s, skipping quoted values
+func closingBrace(s string) int {
+	quoted := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quoted && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == '}':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,199 @@
+package spark
+
+import (
+	"strings"
+	"testing"
+)
+
+const scrapes = `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",code="200"} 10
+http_requests_total{method="POST",code="500"} 1 1700000000000
+http_requests_total{code="200",method="GET"} 15
+
+http_requests_total{method="GET",code="200"} 22.7
+http_requests_total{method="POST",code="500"} 3
+process_open_fds 12
+`
+
+func TestParsePrometheus(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		selector    string
+		skipInvalid bool
+		expected    []Series
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:     "metric name",
+			input:    scrapes,
+			selector: "http_requests_total",
+			expected: []Series{
+				{Label: `{code="200",method="GET"}`, Data: []int{10, 15, 23}},
+				{Label: `{code="500",method="POST"}`, Data: []int{1, 3}},
+			},
+		},
+		{
+			name:     "equal matcher",
+			input:    scrapes,
+			selector: `http_requests_total{method="POST"}`,
+			expected: []Series{{Label: `{code="500",method="POST"}`, Data: []int{1, 3}}},
+		},
+		{
+			name:     "regexp matchers",
+			input:    scrapes,
+			selector: `http_requests_total{code=~"2..", method!~"P.*"}`,
+			expected: []Series{{Label: `{code="200",method="GET"}`, Data: []int{10, 15, 23}}},
+		},
+		{
+			name:     "labels only",
+			input:    scrapes,
+			selector: `{code!="200"}`,
+			expected: []Series{
+				{Label: `http_requests_total{code="500",method="POST"}`, Data: []int{1, 3}},
+				{Label: "process_open_fds", Data: []int{12}},
+			},
+		},
+		{
+			name:     "missing label matches empty value",
+			input:    scrapes,
+			selector: `{method=""}`,
+			expected: []Series{{Label: "process_open_fds", Data: []int{12}}},
+		},
+		{
+			name:     "escaped label values",
+			input:    `errors{msg="say \"hi\", {now}"} 4` + "\n",
+			selector: "errors",
+			expected: []Series{{Label: `{msg="say \"hi\", {now}"}`, Data: []int{4}}},
+		},
+		{
+			name:     "fractional gauges",
+			input:    "ratio 0.25\nratio 0.75\nratio 1.5\nratio -0.6\n",
+			selector: "ratio",
+			expected: []Series{{Label: "ratio", Data: []int{0, 1, 2, -1}}},
+		},
+		{
+			name:        "skip special values",
+			input:       "up 1\nup NaN\nup +Inf\nup 0\n",
+			selector:    "up",
+			skipInvalid: true,
+			expected:    []Series{{Label: "up", Data: []int{1, 0}}},
+		},
+		{
+			name:     "exemplars",
+			input:    "up 1\nfoo_total 17 1520879607.789 # {trace_id=\"x\"} 0.67\nup 0 # {trace_id=\"y\"} 1\n",
+			selector: "up",
+			expected: []Series{{Label: "up", Data: []int{1, 0}}},
+		},
+		{
+			name:     "malformed lines of other metrics",
+			input:    "up 1\nfoo{job=api} 1\nbar 1 2 3\nup 0\n",
+			selector: "up",
+			expected: []Series{{Label: "up", Data: []int{1, 0}}},
+		},
+		{
+			name:        "skip malformed lines",
+			input:       "up 1\nup{job=\"api} 1\n{job=\"x\"} 2\nup 0\n",
+			selector:    "up",
+			skipInvalid: true,
+			expected:    []Series{{Label: "up", Data: []int{1, 0}}},
+		},
+		{
+			name:        "special values",
+			input:       "up 1\nup NaN\n",
+			selector:    "up",
+			expectError: true,
+			errorMsg:    "line 2, column 4: NaN (not a number) not supported: NaN",
+		},
+		{
+			name:        "invalid sample",
+			input:       "up{job=\"api} 1\n",
+			selector:    "up",
+			expectError: true,
+			errorMsg:    "line 1, column 1: invalid sample: up{job=\"api} 1",
+		},
+		{
+			name:        "invalid labels",
+			input:       "up{job=api} 1\n",
+			selector:    "up",
+			expectError: true,
+			errorMsg:    "line 1, column 1: invalid sample, expected quoted value for label job: up{job=api} 1",
+		},
+		{
+			name:        "no matching sample",
+			input:       scrapes,
+			selector:    "up",
+			expectError: true,
+			errorMsg:    ErrNoData.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Prometheus: tt.selector, SkipInvalid: tt.skipInvalid}
+			actual, _, err := parseSeries(strings.NewReader(tt.input), config)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if !seriesEqual(actual, tt.expected) {
+				t.Errorf("got %v, want %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		selector    string
+		expectError bool
+		errorMsg    string
+	}{
+		{selector: "up"},
+		{selector: `up{job="api",}`},
+		{selector: ` {job=~"api|db"} `},
+		{selector: "", expectError: true, errorMsg: "invalid selector, no metric name or labels: "},
+		{selector: "{}", expectError: true, errorMsg: "invalid selector, no metric name or labels: {}"},
+		{selector: `up{job="api"`, expectError: true, errorMsg: `invalid selector, missing closing brace: up{job="api"`},
+		{selector: `up{job}`, expectError: true, errorMsg: `invalid selector, expected label name at "job": up{job}`},
+		{selector: `up{job<"api"}`, expectError: true, errorMsg: `invalid selector, expected label name at "job<\"api\"": up{job<"api"}`},
+		{selector: `up{job="api" env="prod"}`, expectError: true, errorMsg: `invalid selector, expected comma after label job: up{job="api" env="prod"}`},
+		{selector: `up{job=~"("}`, expectError: true, errorMsg: "invalid selector, error parsing regexp: missing closing ): `^(?:()$`: up{job=~\"(\"}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			_, err := ParseSelector(tt.selector)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package spark
+
+import "slices"
+
+type Option func(*Config)
+
+// Renderer draws sparklines with a config validated once by New. It never changes after New,
+// so it can be shared by goroutines.
+type Renderer struct {
+	config Config
+}
+
+func New(options ...Option) (*Renderer, error) {
+	r := &Renderer{}
+	for _, option := range options {
+		option(&r.config)
+	}
+
+	if err := r.config.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Renderer) Render(data []int) (string, error) {
+	return Spark(data, &r.config)
+}
+
+func (r *Renderer) RenderSeries(series []Series) (string, error) {
+	return SparkSeries(series, &r.config)
+}
+
+// WithConfig starts from a copy of config, later options override its fields.
+func WithConfig(config *Config) Option {
+	return func(c *Config) {
+		*c = *config
+		c.Gradient = slices.Clone(config.Gradient)
+		c.MinStyle = slices.Clone(config.MinStyle)
+		c.MaxStyle = slices.Clone(config.MaxStyle)
+		c.LastStyle = slices.Clone(config.LastStyle)
+		c.Transforms = slices.Clone(config.Transforms)
+		if config.Warn != nil {
+			c.Warn = intPtr(*config.Warn)
+		}
+		if config.Crit != nil {
+			c.Crit = intPtr(*config.Crit)
+		}
+	}
+}
+
+func WithBgColor(color string) Option {
+	return func(c *Config) {
+		c.BgColor = color
+	}
+}
+
+func WithFgColor(color string) Option {
+	return func(c *Config) {
+		c.FgColor = color
+	}
+}
+
+func WithGradient(colors ...string) Option {
+	return func(c *Config) {
+		c.Gradient = slices.Clone(colors)
+	}
+}
+
+func WithWarn(threshold int) Option {
+	return func(c *Config) {
+		c.Warn = intPtr(threshold)
+	}
+}
+
+func WithCrit(threshold int) Option {
+	return func(c *Config) {
+		c.Crit = intPtr(threshold)
+	}
+}
+
+func WithWarnColor(color string) Option {
+	return func(c *Config) {
+		c.WarnColor = color
+	}
+}
+
+func WithCritColor(color string) Option {
+	return func(c *Config) {
+		c.CritColor = color
+	}
+}
+
+func WithThresholdMode(mode string) Option {
+	return func(c *Config) {
+		c.ThresholdMode = mode
+	}
+}
+
+func WithMinStyle(style ...string) Option {
+	return func(c *Config) {
+		c.MinStyle = slices.Clone(style)
+	}
+}
+
+func WithMaxStyle(style ...string) Option {
+	return func(c *Config) {
+		c.MaxStyle = slices.Clone(style)
+	}
+}
+
+func WithLastStyle(style ...string) Option {
+	return func(c *Config) {
+		c.LastStyle = slices.Clone(style)
+	}
+}
+
+func WithColorMode(mode string) Option {
+	return func(c *Config) {
+		c.ColorMode = mode
+	}
+}
+
+func WithSum() Option {
+	return func(c *Config) {
+		c.ShowSum = true
+	}
+}
+
+func WithStats() Option {
+	return func(c *Config) {
+		c.ShowStats = true
+	}
+}
+
+func WithBreaches() Option {
+	return func(c *Config) {
+		c.ShowBreaches = true
+	}
+}
+
+func WithTrend() Option {
+	return func(c *Config) {
+		c.ShowTrend = true
+	}
+}
+
+func WithReverse() Option {
+	return func(c *Config) {
+		c.Reverse = true
+	}
+}
+
+func WithVertical() Option {
+	return func(c *Config) {
+		c.Vertical = true
+	}
+}
+
+func WithSharedScale() Option {
+	return func(c *Config) {
+		c.SharedScale = true
+	}
+}
+
+// WithHistogram draws the counts of the values in bins, picked by the Freedman–Diaconis rule when bins is 0
+func WithHistogram(bins int) Option {
+	return func(c *Config) {
+		c.Histogram = true
+		c.Bins = bins
+	}
+}
+
+// WithTransforms runs the data through the transforms, given as for ParseTransform, before drawing it
+func WithTransforms(specs ...string) Option {
+	return func(c *Config) {
+		c.Transforms = slices.Clone(specs)
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,121 @@
+package spark
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRenderer(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		data     []int
+		expected string
+	}{
+		{"no options", nil, []int{1, 2, 3, 4, 5}, "▁▂▄▆█"},
+		{"foreground and stats", []Option{WithFgColor("red"), WithStats()}, []int{1, 2}, "\033[31m▁\033[0m\033[31m█\033[0m (min:1 max:2 avg:1.50)"},
+		{"sum, reverse and vertical", []Option{WithSum(), WithReverse(), WithVertical()}, []int{1, 2, 3}, "█\n▌\n▏ (sum:6)"},
+		{"thresholds", []Option{WithWarn(2), WithCrit(3), WithBreaches()}, []int{1, 2, 3}, "▁\033[33m▄\033[0m\033[31m█\033[0m (warn:1 crit:1)"},
+		{"gradient never colored", []Option{WithGradient("green", "red"), WithColorMode(ColorNever)}, []int{1, 2}, "▁█"},
+		{"histogram", []Option{WithHistogram(2)}, []int{1, 1, 1, 5}, "█▁"},
+		{"transforms", []Option{WithTransforms("cumsum", "normalize"), WithStats()}, []int{1, 1, 2}, "▁▃█ (min:0 max:100 avg:44.33)"},
+		{"trend", []Option{WithTrend()}, []int{3, 2, 1}, "█▄▁ (trend:↘-1.00)"},
+		{"later options win", []Option{WithConfig(&Config{FgColor: "red", ShowSum: true}), WithFgColor("blue")}, []int{1, 2}, "\033[34m▁\033[0m\033[34m█\033[0m (sum:3)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer, err := New(tt.options...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			actual, err := renderer.Render(tt.data)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if actual != tt.expected {
+				t.Errorf("got '%s', want '%s'", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRendererValidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		errorMsg string
+	}{
+		{"invalid foreground", []Option{WithFgColor("purple")}, "invalid color: purple"},
+		{"single gradient color", []Option{WithGradient("red")}, "gradient needs at least two colors: red"},
+		{"negative bins", []Option{WithHistogram(-1)}, "invalid number of bins: -1"},
+		{"invalid thresholds", []Option{WithWarn(90), WithCrit(70)}, "warning threshold 90 must not be above critical threshold 70"},
+		{"invalid style", []Option{WithMaxStyle("sparkly")}, "invalid style: sparkly"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer, err := New(tt.options...)
+			if err == nil {
+				t.Errorf("expected error but got renderer %v", renderer)
+				return
+			}
+			if err.Error() != tt.errorMsg {
+				t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestRendererIsolatedFromCaller(t *testing.T) {
+	gradient := []string{"green", "red"}
+	config := &Config{Gradient: gradient, Warn: intPtr(5)}
+	renderer, err := New(WithConfig(config))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gradient[0] = "purple"
+	*config.Warn = 1
+
+	actual, err := renderer.Render([]int{1, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "\033[32m▁\033[0m\033[31m█\033[0m"; actual != expected {
+		t.Errorf("got '%s', want '%s'", actual, expected)
+	}
+}
+
+func TestRendererConcurrent(t *testing.T) {
+	renderer, err := New(WithGradient("green", "yellow", "red"), WithMaxStyle("bold"), WithStats())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected, _ := renderer.Render([]int{1, 5, 9})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if actual, err := renderer.Render([]int{1, 5, 9}); err != nil || actual != expected {
+					t.Errorf("got '%s', %v, want '%s'", actual, err, expected)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkRenderer(b *testing.B) {
+	renderer, _ := New(WithFgColor("red"), WithStats())
+	for i := 0; i < b.N; i++ {
+		_, _ = renderer.Render([]int{1, 5, 22, 13, 5})
+	}
+}
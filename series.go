@@ -0,0 +1,363 @@
+package spark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	SeriesLines   = "line"
+	SeriesColumns = "column"
+	SeriesLabels  = "label"
+)
+
+type Series struct {
+	Label string
+	Data  []int
+}
+
+func ValidateSeriesMode(mode string) error {
+	if mode == "" || mode == SeriesLines || mode == SeriesColumns || mode == SeriesLabels {
+		return nil
+	}
+
+	return fmt.Errorf("invalid series mode: %s", mode)
+}
+
+// ParseSeries is ParseArgs for several series, read from args, or from stdin when there are no
+// args and stdin is not nil. They are split from the input according to config.SeriesMode: one
+// per line, one per column (named by a header line if there is one) or one per label for
+// "label: values" lines. Lines of the same label are appended to the same series. With
+// config.GroupBy, lines are grouped instead by the value of that column (starting at 1).
+func ParseSeries(args []string, stdin io.Reader, config *Config) ([]Series, int, error) {
+	if len(args) == 0 && stdin != nil {
+		series, skipped, err := parseSeries(stdin, config)
+		return series, skipped, setSource(err, "<stdin>")
+	}
+
+	series, skipped, err := parseSeries(strings.NewReader(strings.Join(args, "\n")), config)
+	return series, skipped, setSource(err, "<args>")
+}
+
+func parseSeries(r io.Reader, config *Config) ([]Series, int, error) {
+	switch {
+	case config.Prometheus != "":
+		return parsePrometheus(r, config)
+	case config.Influx != "":
+		return parseInflux(r, config)
+	case config.Graphite != "":
+		return parseGraphite(r, config)
+	}
+
+	re, err := config.matcher()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var set seriesSet
+	hasColumns := false
+	skipped := 0
+
+	// grouping and columns read several columns out of the capture groups of one match
+	extract := lineTokens
+	if config.GroupBy > 0 || config.SeriesMode == SeriesColumns {
+		extract = lineColumns
+	}
+
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadString('\n')
+		if line == "" && err == io.EOF {
+			break
+		}
+		if line == "" && err != nil {
+			return nil, skipped, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		tokens := extract(line, re)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		switch {
+		case config.GroupBy > 0:
+			label, values, err := groupTokens(tokens, line, lineNo, config)
+			if err != nil && config.SkipInvalid {
+				skipped++
+				continue
+			}
+			if err != nil {
+				return nil, skipped, err
+			}
+
+			set.add(label, values...)
+
+		case config.SeriesMode == SeriesColumns:
+			if !hasColumns {
+				hasColumns = true
+				set.series = make([]Series, len(tokens))
+				if isHeader(tokens) {
+					for i, f := range tokens {
+						set.series[i].Label = f.token
+					}
+					continue
+				}
+			}
+
+			if len(tokens) != len(set.series) {
+				return nil, skipped, &ParseError{Line: lineNo, Column: 1, Token: line, Err: ErrColumnCount}
+			}
+			values, n, err := parseTokens(tokens, lineNo, config)
+			skipped += n
+			if err != nil {
+				return nil, skipped, err
+			}
+			// a row with a skipped cell is dropped whole, so that the later values stay in line
+			if n > 0 {
+				continue
+			}
+			for i, value := range values {
+				set.series[i].Data = append(set.series[i].Data, value)
+			}
+
+		case config.SeriesMode == SeriesLabels:
+			separator := strings.IndexByte(line, ':')
+			if separator < 0 {
+				if config.SkipInvalid {
+					skipped++
+					continue
+				}
+				return nil, skipped, &ParseError{Line: lineNo, Column: 1, Token: line, Err: ErrMissingLabel}
+			}
+
+			label := strings.TrimSpace(line[:separator])
+			tokens = lineTokens(line[separator+1:], re)
+			offset := utf8.RuneCountInString(line[:separator+1])
+			for i := range tokens {
+				tokens[i].column += offset
+			}
+
+			values, n, err := parseTokens(tokens, lineNo, config)
+			skipped += n
+			if err != nil {
+				return nil, skipped, err
+			}
+
+			set.add(label, values...)
+
+		default:
+			values, n, err := parseTokens(tokens, lineNo, config)
+			skipped += n
+			if err != nil {
+				return nil, skipped, err
+			}
+			if len(values) > 0 {
+				set.series = append(set.series, Series{Data: values})
+			}
+		}
+	}
+
+	if len(set.series) == 0 {
+		return nil, skipped, ErrNoData
+	}
+
+	sortSeries(set.series, config)
+
+	return set.series, skipped, nil
+}
+
+// seriesSet collects values into series by label, in first seen order
+type seriesSet struct {
+	series  []Series
+	indexes map[string]int
+}
+
+// add appends values to the series of label, which is created even without any value
+func (s *seriesSet) add(label string, values ...int) {
+	if s.indexes == nil {
+		s.indexes = map[string]int{}
+	}
+
+	i, ok := s.indexes[label]
+	if !ok {
+		i = len(s.series)
+		s.indexes[label] = i
+		s.series = append(s.series, Series{Label: label})
+	}
+	s.series[i].Data = append(s.series[i].Data, values...)
+}
+
+// sortSeries sorts series by label if config says so, keeping the first seen order otherwise
+func sortSeries(series []Series, config *Config) {
+	if config.SortSeries {
+		slices.SortStableFunc(series, func(a, b Series) int {
+			return strings.Compare(a.Label, b.Label)
+		})
+	}
+}
+
+// groupTokens returns the key and value of a line grouped by config.GroupBy
+func groupTokens(tokens []field, line string, lineNo int, config *Config) (string, []int, error) {
+	valueColumn := config.valueColumn()
+	if len(tokens) < max(config.GroupBy, valueColumn) {
+		return "", nil, &ParseError{Line: lineNo, Column: 1, Token: line, Err: ErrColumnCount}
+	}
+
+	values, _, err := parseTokens(tokens[valueColumn-1:valueColumn], lineNo, &Config{})
+	if err != nil {
+		return "", nil, err
+	}
+	return tokens[config.GroupBy-1].token, values, nil
+}
+
+func validateGroups(config *Config) error {
+	if config.GroupBy < 0 {
+		return fmt.Errorf("invalid group-by column: %d", config.GroupBy)
+	}
+	if config.ValueColumn < 0 {
+		return fmt.Errorf("invalid value column: %d", config.ValueColumn)
+	}
+	if config.GroupBy == 0 && config.Bucket == 0 && config.ValueColumn > 0 {
+		return fmt.Errorf("value column needs a group-by column or time buckets")
+	}
+	if config.GroupBy > 0 && config.GroupBy == config.ValueColumn {
+		return fmt.Errorf("group-by and value columns must be different: %d", config.GroupBy)
+	}
+	if config.GroupBy > 0 && config.SeriesMode != "" {
+		return fmt.Errorf("group-by cannot be combined with series mode %s", config.SeriesMode)
+	}
+	return nil
+}
+
+// validateInputFormat checks that at most one input format is given, whose samples are already
+// split into series and cannot be split again
+func validateInputFormat(config *Config) error {
+	formats := map[string]string{"prometheus": config.Prometheus, "influx": config.Influx, "graphite": config.Graphite}
+
+	format := ""
+	for _, name := range []string{"prometheus", "influx", "graphite"} {
+		if formats[name] == "" {
+			continue
+		}
+		if format != "" {
+			return fmt.Errorf("%s input cannot be combined with %s input", format, name)
+		}
+		format = name
+	}
+
+	if format == "" {
+		if config.Tag != "" {
+			return fmt.Errorf("tag grouping needs influx or graphite input")
+		}
+		return nil
+	}
+	if config.SeriesMode != "" {
+		return fmt.Errorf("%s input cannot be combined with series mode %s", format, config.SeriesMode)
+	}
+	if config.GroupBy > 0 {
+		return fmt.Errorf("%s input cannot be combined with group-by", format)
+	}
+	if config.Tag != "" && format == "prometheus" {
+		return fmt.Errorf("tag grouping needs influx or graphite input")
+	}
+	return nil
+}
+
+func lineTokens(line string, re *regexp.Regexp) []field {
+	if re != nil {
+		return extractFields(line, re)
+	}
+	return fields(line)
+}
+
+// lineColumns is lineTokens with every capture group of the pattern as a column
+func lineColumns(line string, re *regexp.Regexp) []field {
+	if re != nil {
+		return extractColumns(line, re)
+	}
+	return fields(line)
+}
+
+// isHeader reports whether a line of columns names them rather than holding numbers
+func isHeader(tokens []field) bool {
+	for _, f := range tokens {
+		if _, err := parseNumber(f.token); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// SparkSeries draws every series on its own line, after its label padded to the longest label.
+// With config.SharedScale all the series are drawn on the range of all their values together,
+// so that they can be compared with each other.
+func SparkSeries(series []Series, config *Config) (string, error) {
+	series, err := transformSeries(series, config)
+	if err != nil {
+		return "", err
+	}
+
+	var shared *scale
+	width := 0
+	for _, s := range series {
+		width = max(width, utf8.RuneCountInString(s.Label))
+
+		if !config.SharedScale || len(s.Data) == 0 {
+			continue
+		}
+		low, high := slices.Min(s.Data), slices.Max(s.Data)
+		if shared == nil {
+			shared = &scale{low: low, high: high}
+		}
+		shared.low = min(shared.low, low)
+		shared.high = max(shared.high, high)
+	}
+
+	lines := make([]string, 0, len(series))
+	for _, s := range series {
+		sparks, err := spark(s.Data, shared, config)
+		if err != nil {
+			if s.Label != "" {
+				return "", fmt.Errorf("%s: %w", s.Label, err)
+			}
+			return "", err
+		}
+
+		switch {
+		case width == 0:
+			lines = append(lines, sparks)
+		case config.Vertical:
+			lines = append(lines, s.Label, sparks)
+		default:
+			lines = append(lines, fmt.Sprintf("%-*s %s", width, s.Label, sparks))
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// transformSeries applies config.Transforms to a copy of every series
+func transformSeries(series []Series, config *Config) ([]Series, error) {
+	if len(config.Transforms) == 0 {
+		return series, nil
+	}
+
+	transformed := make([]Series, len(series))
+	for i, s := range series {
+		data, err := config.transform(s.Data)
+		if err != nil && s.Label != "" {
+			return nil, fmt.Errorf("%s: %w", s.Label, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		transformed[i] = Series{Label: s.Label, Data: data}
+	}
+	return transformed, nil
+}
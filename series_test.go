@@ -0,0 +1,323 @@
+package spark
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseSeries(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		mode        string
+		skipInvalid bool
+		expected    []Series
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:     "per line",
+			input:    "1 2 3\n\n4,5\n",
+			mode:     SeriesLines,
+			expected: []Series{{Data: []int{1, 2, 3}}, {Data: []int{4, 5}}},
+		},
+		{
+			name:     "columns with header",
+			input:    "cpu,mem\n1,50\n5,60\n",
+			mode:     SeriesColumns,
+			expected: []Series{{Label: "cpu", Data: []int{1, 5}}, {Label: "mem", Data: []int{50, 60}}},
+		},
+		{
+			name:     "columns without header",
+			input:    "1 50\n5 60\n",
+			mode:     SeriesColumns,
+			expected: []Series{{Data: []int{1, 5}}, {Data: []int{50, 60}}},
+		},
+		{
+			name:     "labels merged in first seen order",
+			input:    "web: 1 5\ndb: 9\nweb: 3\n",
+			mode:     SeriesLabels,
+			expected: []Series{{Label: "web", Data: []int{1, 5, 3}}, {Label: "db", Data: []int{9}}},
+		},
+		{
+			name:        "ragged columns",
+			input:       "a,b\n1,2\n3\n",
+			mode:        SeriesColumns,
+			expectError: true,
+			errorMsg:    "line 3, column 1: wrong number of columns: 3",
+		},
+		{
+			name:        "missing label",
+			input:       "web: 1\n2 3\n",
+			mode:        SeriesLabels,
+			expectError: true,
+			errorMsg:    "line 2, column 1: missing label, expected label: values: 2 3",
+		},
+		{
+			name:        "invalid value position after label",
+			input:       "web: 1 x\n",
+			mode:        SeriesLabels,
+			expectError: true,
+			errorMsg:    "line 1, column 8: invalid number: x",
+		},
+		{
+			name:        "skip invalid values and lines",
+			input:       "web: 1 x\nnoise\nweb: 2\n",
+			mode:        SeriesLabels,
+			skipInvalid: true,
+			expected:    []Series{{Label: "web", Data: []int{1, 2}}},
+		},
+		{
+			name:        "skip rows with invalid cells",
+			input:       "cpu,mem\n1,50\nn/a,60\n5,55\n",
+			mode:        SeriesColumns,
+			skipInvalid: true,
+			expected:    []Series{{Label: "cpu", Data: []int{1, 5}}, {Label: "mem", Data: []int{50, 55}}},
+		},
+		{
+			name:        "no data",
+			input:       "\n\n",
+			mode:        SeriesLines,
+			expectError: true,
+			errorMsg:    ErrNoData.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{SeriesMode: tt.mode, SkipInvalid: tt.skipInvalid}
+			result, _, err := parseSeries(strings.NewReader(tt.input), config)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if !seriesEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSeriesSources(t *testing.T) {
+	config := &Config{SeriesMode: SeriesLines}
+
+	series, _, err := ParseSeries(nil, strings.NewReader("1 2\n3\n"), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []Series{{Data: []int{1, 2}}, {Data: []int{3}}}; !seriesEqual(series, expected) {
+		t.Errorf("got %v, want %v", series, expected)
+	}
+
+	// arguments win over the reader, which is left unread
+	series, _, err = ParseSeries([]string{"4 5"}, strings.NewReader("1 2\n"), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []Series{{Data: []int{4, 5}}}; !seriesEqual(series, expected) {
+		t.Errorf("got %v, want %v", series, expected)
+	}
+
+	if _, _, err = ParseSeries(nil, nil, config); !errors.Is(err, ErrNoData) {
+		t.Errorf("got error %v, want %v", err, ErrNoData)
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		config      Config
+		expected    []Series
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:     "first seen order",
+			input:    "hostB 7\nhostA 12\nhostB 3\nhostA 15\n",
+			config:   Config{GroupBy: 1, ValueColumn: 2},
+			expected: []Series{{Label: "hostB", Data: []int{7, 3}}, {Label: "hostA", Data: []int{12, 15}}},
+		},
+		{
+			name:     "sorted order",
+			input:    "hostB 7\nhostA 12\nhostB 3\n",
+			config:   Config{GroupBy: 1, ValueColumn: 2, SortSeries: true},
+			expected: []Series{{Label: "hostA", Data: []int{12}}, {Label: "hostB", Data: []int{7, 3}}},
+		},
+		{
+			name:     "default value column",
+			input:    "12 GET /a\n7 POST /b\n9 GET /c\n",
+			config:   Config{GroupBy: 2},
+			expected: []Series{{Label: "GET", Data: []int{12, 9}}, {Label: "POST", Data: []int{7}}},
+		},
+		{
+			name:     "grouped regex captures",
+			input:    "GET /a took 12ms\nPOST /b took 7ms\n",
+			config:   Config{GroupBy: 1, ValueColumn: 2, Match: `(\w+) \S+ took (\d+)ms`},
+			expected: []Series{{Label: "GET", Data: []int{12}}, {Label: "POST", Data: []int{7}}},
+		},
+		{
+			name:     "skip invalid lines",
+			input:    "hostA 1\nhostA\nhostA x\nhostA 2\n",
+			config:   Config{GroupBy: 1, ValueColumn: 2, SkipInvalid: true},
+			expected: []Series{{Label: "hostA", Data: []int{1, 2}}},
+		},
+		{
+			name:        "missing value column",
+			input:       "hostA 1\nhostB\n",
+			config:      Config{GroupBy: 1, ValueColumn: 2},
+			expectError: true,
+			errorMsg:    "line 2, column 1: wrong number of columns: hostB",
+		},
+		{
+			name:        "invalid value",
+			input:       "hostA x\n",
+			config:      Config{GroupBy: 1, ValueColumn: 2},
+			expectError: true,
+			errorMsg:    "line 1, column 7: invalid number: x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, err := parseSeries(strings.NewReader(tt.input), &tt.config)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if !seriesEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSparkSeries(t *testing.T) {
+	tests := []struct {
+		name     string
+		series   []Series
+		config   Config
+		expected string
+	}{
+		{
+			name:     "aligned labels",
+			series:   []Series{{Label: "web", Data: []int{1, 5, 3}}, {Label: "database", Data: []int{9, 2, 4}}},
+			expected: "web      ▁█▄\ndatabase █▁▃",
+		},
+		{
+			name:     "no labels",
+			series:   []Series{{Data: []int{1, 2}}, {Data: []int{2, 1}}},
+			expected: "▁█\n█▁",
+		},
+		{
+			name:     "shared scale",
+			series:   []Series{{Label: "a", Data: []int{1, 2}}, {Label: "b", Data: []int{8, 9}}},
+			config:   Config{SharedScale: true},
+			expected: "a ▁▁\nb ▇█",
+		},
+		{
+			name:     "shared scale keeps own stats",
+			series:   []Series{{Label: "a", Data: []int{1, 2}}, {Label: "b", Data: []int{9}}},
+			config:   Config{SharedScale: true, ShowStats: true},
+			expected: "a ▁▁ (min:1 max:2 avg:1.50)\nb █ (min:9 max:9 avg:9.00)",
+		},
+		{
+			name:     "vertical puts labels on their own line",
+			series:   []Series{{Label: "a", Data: []int{1, 2}}},
+			config:   Config{Vertical: true},
+			expected: "a\n▏\n█",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := SparkSeries(tt.series, &tt.config)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if actual != tt.expected {
+				t.Errorf("got '%s', want '%s'", actual, tt.expected)
+			}
+		})
+	}
+
+	_, err := SparkSeries([]Series{{Label: "big", Data: []int{1 << 62, 1 << 62}}}, &Config{})
+	if !errors.Is(err, ErrOverflow) || !strings.HasPrefix(err.Error(), "big: ") {
+		t.Errorf("expected overflow error for series big, got %v", err)
+	}
+}
+
+func seriesEqual(a, b []Series) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Label != b[i].Label || !sliceEqual(a[i].Data, b[i].Data) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestValidateInputFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		errorMsg string
+	}{
+		{"prometheus", Config{Prometheus: "up"}, ""},
+		{"influx with tag", Config{Influx: "cpu.usage", Tag: "host"}, ""},
+		{"graphite with tag", Config{Graphite: "load", Tag: "host"}, ""},
+		{"two formats", Config{Prometheus: "up", Graphite: "load"}, "prometheus input cannot be combined with graphite input"},
+		{"series mode", Config{Prometheus: "up", SeriesMode: SeriesLines}, "prometheus input cannot be combined with series mode line"},
+		{"group-by", Config{Influx: "usage", GroupBy: 1}, "influx input cannot be combined with group-by"},
+		{"tag without format", Config{Tag: "host"}, "tag grouping needs influx or graphite input"},
+		{"tag with prometheus", Config{Prometheus: "up", Tag: "host"}, "tag grouping needs influx or graphite input"},
+		{"invalid selector", Config{Prometheus: "{}"}, "invalid selector, no metric name or labels: {}"},
+		{"invalid pattern", Config{Graphite: "servers.{a,b"}, "invalid graphite pattern: servers.{a,b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.errorMsg {
+				t.Errorf("expected error message '%s', got '%v'", tt.errorMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package spark
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatSVG  = "svg"
+	FormatPNG  = "png"
+)
+
+// formats are the formats in order of preference, when an Accept header such as image/* allows several
+var formats = []string{FormatText, FormatJSON, FormatSVG, FormatPNG}
+
+var (
+	// ContentTypes maps every format to the media type it is served as
+	ContentTypes = map[string]string{
+		FormatText: "text/plain; charset=utf-8",
+		FormatJSON: "application/json",
+		FormatSVG:  "image/svg+xml",
+		FormatPNG:  "image/png",
+	}
+
+	// RGBMap maps every color name to the RGB value it is drawn with in images
+	RGBMap = map[string]color.RGBA{
+		"black":   {0x00, 0x00, 0x00, 0xff},
+		"red":     {0xcd, 0x31, 0x31, 0xff},
+		"green":   {0x0d, 0xbc, 0x79, 0xff},
+		"yellow":  {0xe5, 0xe5, 0x10, 0xff},
+		"blue":    {0x24, 0x72, 0xc8, 0xff},
+		"magenta": {0xbc, 0x3f, 0xbc, 0xff},
+		"cyan":    {0x11, 0xa8, 0xcd, 0xff},
+		"white":   {0xe5, 0xe5, 0xe5, 0xff},
+	}
+
+	// defaultRGB is used for bars without a foreground color
+	defaultRGB = color.RGBA{0x80, 0x80, 0x80, 0xff}
+)
+
+const (
+	barWidth = 3 // width of a bar in images, followed by a one pixel gap
+	barStep  = 2 // height added to a bar in images for every level
+
+	tickLevels = 8
+
+	// maxImagePoints bounds the number of bars of an image, which is as wide as 4 pixels per bar
+	maxImagePoints = 4096
+)
+
+// sparkResponse is the body of a JSON response
+type sparkResponse struct {
+	Sparkline string         `json:"sparkline"`
+	Values    []int          `json:"values"`
+	Sum       int            `json:"sum"`
+	Min       int            `json:"min"`
+	Max       int            `json:"max"`
+	Avg       float64        `json:"avg"`
+	Counts    []int          `json:"counts,omitempty"`
+	Edges     []float64      `json:"edges,omitempty"`
+	Trend     *trendResponse `json:"trend,omitempty"`
+}
+
+// trendResponse is the least-squares trend of the values, in JSON responses with trend=1
+type trendResponse struct {
+	Direction string  `json:"direction"`
+	Slope     float64 `json:"slope"`
+	Intercept float64 `json:"intercept"`
+}
+
+// NewHandler serves GET /spark, drawing the values of the query string with config as defaults
+// for the other parameters. The format is taken from the format parameter, or else from the
+// Accept header, and defaults to text.
+func NewHandler(config *Config) http.Handler {
+	defaults := *config
+	// there is no terminal to detect over HTTP, so text is only colored with color=always
+	if defaults.ColorMode == "" {
+		defaults.ColorMode = ColorAuto
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /spark", func(w http.ResponseWriter, r *http.Request) {
+		serveSpark(w, r, &defaults)
+	})
+	return mux
+}
+
+func serveSpark(w http.ResponseWriter, r *http.Request, defaults *Config) {
+	// errors depend on the Accept header too, as it picks the format
+	w.Header().Set("Vary", "Accept")
+	query := r.URL.Query()
+
+	format, err := negotiateFormat(query.Get("format"), r.Header.Get("Accept"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config, err := queryConfig(query, defaults)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := config.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, _, err := parseSource(query["values"], config)
+	if errors.Is(err, ErrNoData) {
+		http.Error(w, "no values to draw", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "values: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// transforms run once here, as the images and JSON are drawn without Spark
+	if data, err = config.transform(data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	config.Transforms = nil
+	if len(data) == 0 {
+		http.Error(w, ErrNoValuesLeft.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err := render(data, format, config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypes[format])
+	_, _ = w.Write(body)
+}
+
+// negotiateFormat picks the format parameter if given, or else the format the Accept header prefers
+// by quality, then by order
+func negotiateFormat(format, accept string) (string, error) {
+	if format != "" {
+		if _, ok := ContentTypes[format]; !ok {
+			return "", fmt.Errorf("invalid format: %s", format)
+		}
+		return format, nil
+	}
+
+	type accepted struct {
+		mediaType string
+		quality   float64
+	}
+	var types []accepted
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, exists := params["q"]; exists {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			types = append(types, accepted{mediaType, quality})
+		}
+	}
+	// the most preferred types come first, in the order listed when as preferred
+	slices.SortStableFunc(types, func(a, b accepted) int {
+		return cmp.Compare(b.quality, a.quality)
+	})
+
+	for _, t := range types {
+		for _, name := range formats {
+			if mediaTypeMatches(t.mediaType, ContentTypes[name]) {
+				return name, nil
+			}
+		}
+	}
+	return FormatText, nil
+}
+
+// mediaTypeMatches reports whether an accepted media type such as image/* covers contentType
+func mediaTypeMatches(accepted, contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if accepted == "*/*" || accepted == mediaType {
+		return true
+	}
+	prefix, found := strings.CutSuffix(accepted, "/*")
+	return found && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// queryConfig returns a copy of defaults with the parameters of query applied, named like the flags
+// of the command, except for fg and bg
+func queryConfig(query url.Values, defaults *Config) (*Config, error) {
+	config := *defaults
+
+	texts := map[string]*string{
+		"fg":             &config.FgColor,
+		"bg":             &config.BgColor,
+		"warn-color":     &config.WarnColor,
+		"crit-color":     &config.CritColor,
+		"threshold-mode": &config.ThresholdMode,
+		"color":          &config.ColorMode,
+		"match":          &config.Match,
+		"field":          &config.Field,
+	}
+	for name, value := range texts {
+		if query.Has(name) {
+			*value = query.Get(name)
+		}
+	}
+
+	lists := map[string]*[]string{
+		"gradient":   &config.Gradient,
+		"min-style":  &config.MinStyle,
+		"max-style":  &config.MaxStyle,
+		"last-style": &config.LastStyle,
+		"transform":  &config.Transforms,
+	}
+	for name, value := range lists {
+		if query.Has(name) {
+			*value = splitList(query.Get(name))
+		}
+	}
+
+	booleans := map[string]*bool{
+		"sum":          &config.ShowSum,
+		"stats":        &config.ShowStats,
+		"breaches":     &config.ShowBreaches,
+		"reverse":      &config.Reverse,
+		"vertical":     &config.Vertical,
+		"skip-invalid": &config.SkipInvalid,
+		"histogram":    &config.Histogram,
+		"trend":        &config.ShowTrend,
+	}
+	for name, value := range booleans {
+		if !query.Has(name) {
+			continue
+		}
+		b, err := strconv.ParseBool(query.Get(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", name, query.Get(name))
+		}
+		*value = b
+	}
+
+	if query.Has("bins") {
+		n, err := strconv.Atoi(query.Get("bins"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bins: %s", query.Get("bins"))
+		}
+		config.Bins = n
+	}
+
+	thresholds := map[string]**int{
+		"warn": &config.Warn,
+		"crit": &config.Crit,
+	}
+	for name, value := range thresholds {
+		if !query.Has(name) {
+			continue
+		}
+		n, err := strconv.Atoi(query.Get(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", name, query.Get(name))
+		}
+		*value = &n
+	}
+
+	return &config, nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func render(data []int, format string, config *Config) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return renderJSON(data, config)
+	case FormatSVG:
+		return renderImage(data, config, writeSVG)
+	case FormatPNG:
+		return renderImage(data, config, writePNG)
+	}
+
+	sparks, err := Spark(data, config)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(sparks + "\n"), nil
+}
+
+func renderJSON(data []int, config *Config) ([]byte, error) {
+	plain := *config
+	plain.ColorMode = ColorNever
+	plain.ShowSum, plain.ShowStats, plain.ShowBreaches, plain.ShowTrend = false, false, false, false
+
+	points, counts, edges, err := histogramPoints(data, config)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := layout(points, nil, &plain)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.summary.setValues(data); err != nil {
+		return nil, err
+	}
+
+	response := sparkResponse{
+		Sparkline: concatenateParts(g.ticks, g.styles, g.summary, g.separator, &plain),
+		Values:    data,
+		Sum:       g.summary.sum,
+		Min:       g.summary.minimum,
+		Max:       g.summary.maximum,
+		Avg:       g.summary.average,
+		Counts:    counts,
+		Edges:     edges,
+	}
+	if config.ShowTrend {
+		trend, err := LinearTrend(data)
+		if err != nil {
+			return nil, err
+		}
+		response.Trend = &trendResponse{Direction: trend.Direction(), Slope: trend.Slope, Intercept: trend.Intercept}
+	}
+	return json.Marshal(response)
+}
+
+// histogramPoints returns the points to draw, which are the bin counts in histogram mode, along
+// with the counts and edges of the bins if any
+func histogramPoints(data []int, config *Config) ([]int, []int, []float64, error) {
+	if !config.Histogram {
+		return data, nil, nil, nil
+	}
+	counts, edges, err := Histogram(data, config.Bins)
+	return counts, counts, edges, err
+}
+
+// bar is one point of a graph drawn as an image
+type bar struct {
+	rect  image.Rectangle
+	color color.RGBA
+}
+
+// renderImage draws one bar per point, growing upwards, or rightwards for vertical graphs
+func renderImage(data []int, config *Config, write func(*bytes.Buffer, image.Rectangle, *color.RGBA, []bar) error) ([]byte, error) {
+	points, _, _, err := histogramPoints(data, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) > maxImagePoints {
+		return nil, fmt.Errorf("too many values for an image: %d, at most %d", len(points), maxImagePoints)
+	}
+
+	g, err := layout(points, nil, config)
+	if err != nil {
+		return nil, err
+	}
+
+	length := len(g.levels) * (barWidth + 1)
+	depth := tickLevels * barStep
+
+	bars := make([]bar, len(g.levels))
+	for i, level := range g.levels {
+		height := (level + 1) * barStep
+		r := image.Rect(i*(barWidth+1), depth-height, i*(barWidth+1)+barWidth, depth)
+		if config.Vertical {
+			r = image.Rect(0, i*(barWidth+1), height, i*(barWidth+1)+barWidth)
+		}
+
+		rgb := defaultRGB
+		if g.styles[i].fgColor != "" {
+			rgb = RGBMap[g.styles[i].fgColor]
+		}
+		bars[i] = bar{rect: r, color: rgb}
+	}
+
+	bounds := image.Rect(0, 0, length, depth)
+	if config.Vertical {
+		bounds = image.Rect(0, 0, depth, length)
+	}
+
+	var background *color.RGBA
+	if config.BgColor != "" {
+		rgb := RGBMap[config.BgColor]
+		background = &rgb
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, bounds, background, bars); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeSVG(buf *bytes.Buffer, bounds image.Rectangle, background *color.RGBA, bars []bar) error {
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		bounds.Dx(), bounds.Dy(), bounds.Dx(), bounds.Dy())
+	if background != nil {
+		fmt.Fprintf(buf, `<rect width="100%%" height="100%%" fill="%s"/>`, hexColor(*background))
+	}
+	for _, b := range bars {
+		fmt.Fprintf(buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+			b.rect.Min.X, b.rect.Min.Y, b.rect.Dx(), b.rect.Dy(), hexColor(b.color))
+	}
+	buf.WriteString("</svg>\n")
+	return nil
+}
+
+func writePNG(buf *bytes.Buffer, bounds image.Rectangle, background *color.RGBA, bars []bar) error {
+	img := image.NewRGBA(bounds)
+	if background != nil {
+		draw.Draw(img, bounds, image.NewUniform(*background), image.Point{}, draw.Src)
+	}
+	for _, b := range bars {
+		draw.Draw(img, b.rect, image.NewUniform(b.color), image.Point{}, draw.Src)
+	}
+	return png.Encode(buf, img)
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,187 @@
+package spark
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		accept      string
+		contentType string
+		expected    string
+	}{
+		{"text", "values=1,5,22,13,53", "", "text/plain; charset=utf-8", "▁▁▃▂█\n"},
+		{"text stats", "values=1,2,3,4,5&stats=1", "", "text/plain; charset=utf-8", "▁▂▄▆█ (min:1 max:5 avg:3.00)\n"},
+		{"text without colors", "values=1,2&fg=red", "", "text/plain; charset=utf-8", "▁█\n"},
+		{"text with colors", "values=1,2&fg=red&color=always", "", "text/plain; charset=utf-8", "\033[31m▁\033[0m\033[31m█\033[0m\n"},
+		{"repeated values", "values=1&values=2", "", "text/plain; charset=utf-8", "▁█\n"},
+		{"json", "values=1,2,3&format=json&stats=1", "", "application/json", `{"sparkline":"▁▄█","values":[1,2,3],"sum":6,"min":1,"max":3,"avg":2}`},
+		{"json accepted", "values=1,2,3", "text/html, application/json;q=0.9", "application/json", `{"sparkline":"▁▄█","values":[1,2,3],"sum":6,"min":1,"max":3,"avg":2}`},
+		{"text histogram", "values=1,2,2,3,9&histogram=1&bins=4&stats=1", "", "text/plain; charset=utf-8", "█▃▁▃ (min:1 max:9 avg:3.40 edges:1,3,5,7,9)\n"},
+		{"json histogram", "values=1,2,2,3,9&format=json&histogram=1&bins=2", "", "application/json",
+			`{"sparkline":"█▁","values":[1,2,2,3,9],"sum":17,"min":1,"max":9,"avg":3.4,"counts":[4,1],"edges":[1,5,9]}`},
+		{"json transformed", "values=1,3,6&format=json&transform=cumsum,diff", "", "application/json", `{"sparkline":"▁█","values":[3,6],"sum":9,"min":3,"max":6,"avg":4.5}`},
+		{"json trend", "values=1,2,3&format=json&trend=1", "", "application/json",
+			`{"sparkline":"▁▄█","values":[1,2,3],"sum":6,"min":1,"max":3,"avg":2,"trend":{"direction":"↗","slope":1,"intercept":1}}`},
+		{"svg", "values=1,2&fg=red&bg=black&format=svg", "", "image/svg+xml",
+			`<svg xmlns="http://www.w3.org/2000/svg" width="8" height="16" viewBox="0 0 8 16">` +
+				`<rect width="100%" height="100%" fill="#000000"/>` +
+				`<rect x="0" y="14" width="3" height="2" fill="#cd3131"/>` +
+				`<rect x="4" y="0" width="3" height="16" fill="#cd3131"/>` +
+				"</svg>\n"},
+		{"svg vertical", "values=3,3&vertical=1", "image/svg+xml", "image/svg+xml",
+			`<svg xmlns="http://www.w3.org/2000/svg" width="16" height="8" viewBox="0 0 16 8">` +
+				`<rect x="0" y="0" width="10" height="3" fill="#808080"/>` +
+				`<rect x="0" y="4" width="10" height="3" fill="#808080"/>` +
+				"</svg>\n"},
+	}
+
+	handler := NewHandler(&Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/spark?"+tt.query, nil)
+			if tt.accept != "" {
+				request.Header.Set("Accept", tt.accept)
+			}
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			if response.Code != http.StatusOK {
+				t.Fatalf("got status %d: %s", response.Code, response.Body.String())
+			}
+			if contentType := response.Header().Get("Content-Type"); contentType != tt.contentType {
+				t.Errorf("got content type '%s', want '%s'", contentType, tt.contentType)
+			}
+			if actual := response.Body.String(); actual != tt.expected {
+				t.Errorf("got '%s', want '%s'", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		expected string
+	}{
+		{"no header", "", FormatText},
+		{"first listed", "application/json, image/svg+xml", FormatJSON},
+		{"highest quality", "image/svg+xml;q=0.1, application/json", FormatJSON},
+		{"quality ties keep the listed order", "image/png;q=0.5, application/json;q=0.5", FormatPNG},
+		{"any image", "image/*", FormatSVG},
+		{"any type", "*/*", FormatText},
+		{"not acceptable", "application/json;q=0, image/png;q=0.2", FormatPNG},
+		{"unknown types", "application/xml", FormatText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := negotiateFormat("", tt.accept)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if format != tt.expected {
+				t.Errorf("got format %s, want %s", format, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHandlerPNG(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/spark?values=1,5,9&gradient=green,red", nil)
+	request.Header.Set("Accept", "image/png")
+	response := httptest.NewRecorder()
+	NewHandler(&Config{}).ServeHTTP(response, request)
+
+	img, err := png.Decode(bytes.NewReader(response.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 12 || size.Y != 16 {
+		t.Errorf("got size %v, want 12x16", size)
+	}
+
+	// the top of the last bar is red, above the first bar is transparent
+	if r, g, b, _ := img.At(9, 0).RGBA(); r>>8 != 0xcd || g>>8 != 0x31 || b>>8 != 0x31 {
+		t.Errorf("got color %v at the top of the last bar, want red", img.At(9, 0))
+	}
+	if _, _, _, a := img.At(1, 0).RGBA(); a != 0 {
+		t.Errorf("got color %v above the first bar, want transparent", img.At(1, 0))
+	}
+}
+
+func TestHandlerDefaults(t *testing.T) {
+	config := &Config{ShowSum: true, FgColor: "red", ColorMode: ColorAlways}
+	handler := NewHandler(config)
+	config.ShowSum = false
+
+	request := httptest.NewRequest(http.MethodGet, "/spark?values=1,2&fg=blue", nil)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if expected := "\033[34m▁\033[0m\033[34m█\033[0m (sum:3)\n"; response.Body.String() != expected {
+		t.Errorf("got '%s', want '%s'", response.Body.String(), expected)
+	}
+
+	var body map[string]any
+	request = httptest.NewRequest(http.MethodGet, "/spark?values=1,2&format=json", nil)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if err := json.Unmarshal(response.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body["sparkline"] != "▁█" {
+		t.Errorf("got sparkline '%v', want '▁█'", body["sparkline"])
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		status   int
+		errorMsg string
+	}{
+		{"no values", http.MethodGet, "/spark", http.StatusBadRequest, "no values to draw"},
+		{"invalid value", http.MethodGet, "/spark?values=1,abc", http.StatusBadRequest, "values: line 1, column 3: invalid number: abc"},
+		{"invalid color", http.MethodGet, "/spark?values=1&fg=purple", http.StatusBadRequest, "invalid color: purple"},
+		{"invalid boolean", http.MethodGet, "/spark?values=1&stats=maybe", http.StatusBadRequest, "invalid stats: maybe"},
+		{"invalid threshold", http.MethodGet, "/spark?values=1&warn=high", http.StatusBadRequest, "invalid warn: high"},
+		{"invalid bins", http.MethodGet, "/spark?values=1&histogram=1&bins=many", http.StatusBadRequest, "invalid bins: many"},
+		{"invalid transform", http.MethodGet, "/spark?values=1&transform=smooth", http.StatusBadRequest, "invalid transform: smooth"},
+		{"nothing left to draw", http.MethodGet, "/spark?values=5&transform=diff&format=json", http.StatusBadRequest, "transforms left no values to draw"},
+		{"nothing left to draw as svg", http.MethodGet, "/spark?values=5&transform=diff&format=svg", http.StatusBadRequest, "transforms left no values to draw"},
+		{"invalid format", http.MethodGet, "/spark?values=1&format=gif", http.StatusBadRequest, "invalid format: gif"},
+		{"too many values for an image", http.MethodGet, "/spark?format=png&values=" + strings.Repeat("1,", 4096) + "1", http.StatusBadRequest, "too many values for an image: 4097, at most 4096"},
+		{"wrong method", http.MethodPost, "/spark?values=1", http.StatusMethodNotAllowed, ""},
+		{"unknown path", http.MethodGet, "/graph?values=1", http.StatusNotFound, ""},
+	}
+
+	handler := NewHandler(&Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if response.Code != tt.status {
+				t.Errorf("got status %d, want %d", response.Code, tt.status)
+			}
+			if vary := response.Header().Get("Vary"); tt.status == http.StatusBadRequest && vary != "Accept" {
+				t.Errorf("got Vary '%s', want 'Accept'", vary)
+			}
+			if !strings.Contains(response.Body.String(), tt.errorMsg) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.errorMsg, response.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package spark
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const EnvPrefix = "GOSPARK_"
+
+// DefaultConfigPath returns $XDG_CONFIG_HOME/gospark/config.toml, or ~/.config/gospark/config.toml
+// when XDG_CONFIG_HOME is not set.
+func DefaultConfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gospark", "config.toml"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "gospark", "config.toml"), nil
+}
+
+// LoadSettings reads the settings of a config file, keyed by flag name with values as they would
+// be given on the command line. The settings of profile, from its [profiles.<name>] table,
+// override the top level ones. A missing file has no settings unless it is required.
+func LoadSettings(path, profile string, required bool) (map[string]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		if profile != "" {
+			return nil, fmt.Errorf("unknown profile: %s", profile)
+		}
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	tables, err := parseSettings(file, path)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := tables[""]
+	if profile == "" {
+		return settings, nil
+	}
+
+	overrides, exists := tables["profiles."+profile]
+	if !exists {
+		return nil, fmt.Errorf("unknown profile: %s", profile)
+	}
+	for key, value := range overrides {
+		settings[key] = value
+	}
+	return settings, nil
+}
+
+// EnvSettings returns the settings of GOSPARK_* variables, GOSPARK_WARN_COLOR being the warn-color flag.
+func EnvSettings(environ []string) map[string]string {
+	settings := map[string]string{}
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+		if !strings.HasPrefix(name, EnvPrefix) || name == EnvPrefix {
+			continue
+		}
+		key := strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), "_", "-")
+		settings[key] = value
+	}
+	return settings
+}
+
+// EnvVariable returns the GOSPARK_* variable of the setting key, GOSPARK_WARN_COLOR for warn-color.
+func EnvVariable(key string) string {
+	return EnvPrefix + strings.ReplaceAll(strings.ToUpper(key), "-", "_")
+}
+
+// parseSettings parses the subset of TOML needed by settings: tables, comments and key/value
+// pairs of strings, booleans, numbers and arrays of them, which may span several lines. Arrays of
+// tables, inline tables, multi-line strings and nested arrays are rejected as unsupported.
+func parseSettings(r io.Reader, path string) (map[string]map[string]string, error) {
+	tables := map[string]map[string]string{"": {}}
+	table := ""
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[[") {
+			return nil, fmt.Errorf("%s:%d: unsupported TOML, settings cannot be arrays of tables: %s", path, lineNo, line)
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s:%d: invalid table: %s", path, lineNo, line)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			if _, exists := tables[table]; !exists {
+				tables[table] = map[string]string{}
+			}
+			continue
+		}
+
+		key, raw, found := strings.Cut(line, "=")
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		if !found || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected key = value: %s", path, lineNo, line)
+		}
+
+		// an array goes on over the next lines until its closing bracket
+		raw = strings.TrimSpace(raw)
+		start := lineNo
+		for strings.HasPrefix(raw, "[") && !arrayClosed(raw) && scanner.Scan() {
+			lineNo++
+			raw += " " + strings.TrimSpace(stripComment(scanner.Text()))
+		}
+
+		value, err := parseSettingValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, start, err)
+		}
+		tables[table][key] = value
+	}
+
+	return tables, scanner.Err()
+}
+
+func parseSettingValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"""`) || strings.HasPrefix(raw, "'''"):
+		return "", fmt.Errorf("unsupported TOML, settings cannot be multi-line strings: %s", raw)
+	case strings.HasPrefix(raw, `"`):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, "'") && strings.HasSuffix(raw, "'") && len(raw) > 1:
+		return raw[1 : len(raw)-1], nil
+	case strings.HasPrefix(raw, "{"):
+		return "", fmt.Errorf("unsupported TOML, settings cannot be inline tables: %s", raw)
+	case strings.HasPrefix(raw, "["):
+		if !arrayClosed(raw) || !strings.HasSuffix(raw, "]") {
+			return "", fmt.Errorf("invalid array: %s", raw)
+		}
+		var items []string
+		for _, item := range splitArray(raw[1 : len(raw)-1]) {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			if strings.HasPrefix(item, "[") {
+				return "", fmt.Errorf("unsupported TOML, settings cannot be nested arrays: %s", raw)
+			}
+			value, err := parseSettingValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, value)
+		}
+		return strings.Join(items, ","), nil
+	case raw == "true" || raw == "false":
+		return raw, nil
+	}
+
+	if _, err := strconv.ParseFloat(raw, 64); err != nil {
+		return "", fmt.Errorf("invalid value: %s", raw)
+	}
+	return raw, nil
+}
+
+// arrayClosed reports whether the brackets of an array value are balanced, ignoring the ones
+// inside quoted strings
+func arrayClosed(raw string) bool {
+	depth := 0
+	scanQuoted(raw, func(i int, r rune) bool {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		return true
+	})
+	return depth <= 0
+}
+
+// splitArray splits the items of an array at the commas outside quoted strings and nested arrays
+func splitArray(items string) []string {
+	var parts []string
+	depth, start := 0, 0
+	scanQuoted(items, func(i int, r rune) bool {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, items[start:i])
+			start = i + 1
+		}
+		return true
+	})
+	return append(parts, items[start:])
+}
+
+// stripComment removes a trailing comment, ignoring # inside quoted strings
+func stripComment(line string) string {
+	end := len(line)
+	scanQuoted(line, func(i int, r rune) bool {
+		if r == '#' {
+			end = i
+			return false
+		}
+		return true
+	})
+	return line[:end]
+}
+
+// scanQuoted calls visit with the runes of s outside quoted strings and their index, until visit
+// returns false
+func scanQuoted(s string, visit func(i int, r rune) bool) {
+	var quote rune
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0:
+			if !visit(i, r) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package spark
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testSettings = `# defaults for every run
+stats = true          # always show stats
+fgcolor = "cyan"
+match = 'took (\d+)ms'
+
+[profiles.cpu]
+gradient = ["green", "yellow", "red"]
+warn = 70
+fgcolor = "white # not a comment"
+
+[profiles.disk]
+threshold-mode = "below"
+colors = [
+  "green",  # healthy
+  "red,dark",
+]
+`
+
+func TestLoadSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(testSettings), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		profile     string
+		required    bool
+		expected    map[string]string
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:     "top level settings",
+			path:     path,
+			expected: map[string]string{"stats": "true", "fgcolor": "cyan", "match": `took (\d+)ms`},
+		},
+		{
+			name:     "profile overrides top level",
+			path:     path,
+			profile:  "cpu",
+			expected: map[string]string{"stats": "true", "fgcolor": "white # not a comment", "match": `took (\d+)ms`, "gradient": "green,yellow,red", "warn": "70"},
+		},
+		{
+			name:     "multi-line array",
+			path:     path,
+			profile:  "disk",
+			expected: map[string]string{"stats": "true", "fgcolor": "cyan", "match": `took (\d+)ms`, "threshold-mode": "below", "colors": "green,red,dark"},
+		},
+		{
+			name:     "missing optional file",
+			path:     filepath.Join(t.TempDir(), "missing.toml"),
+			expected: map[string]string{},
+		},
+		{
+			name:        "missing required file",
+			path:        filepath.Join(t.TempDir(), "missing.toml"),
+			required:    true,
+			expectError: true,
+		},
+		{
+			name:        "unknown profile",
+			path:        path,
+			profile:     "memory",
+			expectError: true,
+			errorMsg:    "unknown profile: memory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := LoadSettings(tt.path, tt.profile, tt.required)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if tt.errorMsg != "" && err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if !maps.Equal(settings, tt.expected) {
+				t.Errorf("got %v, want %v", settings, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadSettingsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		errorMsg string
+	}{
+		{"missing value", "stats\n", ":1: expected key = value: stats"},
+		{"invalid table", "[profiles.cpu\n", ":1: invalid table: [profiles.cpu"},
+		{"invalid bare value", "\nfgcolor = cyan\n", ":2: invalid value: cyan"},
+		{"unterminated string", `fgcolor = "cyan` + "\n", ":1: invalid syntax"},
+		{"unterminated array", `gradient = ["red"` + "\n", `:1: invalid array: ["red"`},
+		{"unterminated multi-line array", "\ngradient = [\n\"red\",\n", `:2: invalid array: [ "red",`},
+		{"array of tables", "[[profiles]]\n", ":1: unsupported TOML, settings cannot be arrays of tables: [[profiles]]"},
+		{"inline table", "colors = {warn = \"red\"}\n", `:1: unsupported TOML, settings cannot be inline tables: {warn = "red"}`},
+		{"multi-line string", `match = """took"""` + "\n", `:1: unsupported TOML, settings cannot be multi-line strings: """took"""`},
+		{"nested array", "gradient = [[1, 2]]\n", ":1: unsupported TOML, settings cannot be nested arrays: [[1, 2]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+
+			_, err := LoadSettings(path, "", true)
+			if err == nil {
+				t.Errorf("expected error but got none")
+				return
+			}
+			if err.Error() != path+tt.errorMsg {
+				t.Errorf("expected error message '%s', got '%s'", path+tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestEnvSettings(t *testing.T) {
+	environ := []string{
+		"HOME=/root",
+		"GOSPARK_FGCOLOR=red",
+		"GOSPARK_WARN_COLOR=magenta",
+		"GOSPARK_STATS=true",
+		"GOSPARK_=ignored",
+		"GOSPARKLE=ignored",
+	}
+	expected := map[string]string{"fgcolor": "red", "warn-color": "magenta", "stats": "true"}
+
+	if settings := EnvSettings(environ); !maps.Equal(settings, expected) {
+		t.Errorf("got %v, want %v", settings, expected)
+	}
+	if variable := EnvVariable("warn-color"); variable != "GOSPARK_WARN_COLOR" {
+		t.Errorf("got variable %s, want GOSPARK_WARN_COLOR", variable)
+	}
+}
+
+func TestDefaultConfigPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	if path, err := DefaultConfigPath(); err != nil || path != "/tmp/xdg/gospark/config.toml" {
+		t.Errorf("got %s, %v, want /tmp/xdg/gospark/config.toml", path, err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "/home/spark")
+	if path, err := DefaultConfigPath(); err != nil || path != "/home/spark/.config/gospark/config.toml" {
+		t.Errorf("got %s, %v, want /home/spark/.config/gospark/config.toml", path, err)
+	}
+}
@@ -9,34 +9,181 @@ import (
 )
 
 func Spark(data []int, config *Config) (string, error) {
+	transformed, err := config.transform(data)
+	if err != nil {
+		return "", err
+	}
+	// transforms such as diff leave nothing of a single point, which is not the same as no input
+	if len(transformed) == 0 && len(data) > 0 {
+		return "", ErrNoValuesLeft
+	}
+	data = transformed
+	if config.Histogram {
+		return sparkHistogram(data, config)
+	}
+	return spark(data, nil, config)
+}
+
+// SparkGaps draws data like Spark, with a blank tick for every point marked missing in gaps, which
+// holds one entry per point. Missing points are left out of the scale, highlights and stats, and
+// out of the transforms too: the points diff or rate would compute across a gap are left blank.
+func SparkGaps(data []int, gaps []bool, config *Config) (string, error) {
+	if len(gaps) != len(data) {
+		return "", fmt.Errorf("got %d gaps for %d points", len(gaps), len(data))
+	}
+
+	transformed, missing, err := config.transformGaps(data, gaps)
+	if err != nil {
+		return "", err
+	}
+	if len(transformed) == 0 && slices.Contains(gaps, false) {
+		return "", ErrNoValuesLeft
+	}
+	if len(transformed) == 0 {
+		return "", ErrNoData
+	}
+
+	g, err := layout(transformed, nil, config)
+	if err != nil {
+		return "", err
+	}
+
+	if g.summary.trend != nil {
+		var positions []int
+		for i, gap := range missing {
+			if !gap {
+				positions = append(positions, i)
+			}
+		}
+		// the line is fitted across the gaps, so that a point after them counts as later
+		trend, err := fitTrend(positions, transformed)
+		if err != nil {
+			return "", err
+		}
+		g.summary.trend = &trend
+	}
+	if config.Reverse {
+		slices.Reverse(missing)
+	}
+
+	ticks := make([]rune, 0, len(data))
+	styles := make([]tickStyle, 0, len(data))
+	next := 0
+	for _, gap := range missing {
+		if gap {
+			ticks = append(ticks, ' ')
+			styles = append(styles, tickStyle{})
+			continue
+		}
+		ticks = append(ticks, g.ticks[next])
+		styles = append(styles, g.styles[next])
+		next++
+	}
+
+	return concatenateParts(ticks, styles, g.summary, g.separator, config), nil
+}
+
+// scale is the range of values mapped onto the ticks, shared by several graphs drawn on one scale
+type scale struct {
+	low  int
+	high int
+}
+
+// spark draws data on the shared scale if given, or else on the range of data itself
+func spark(data []int, shared *scale, config *Config) (string, error) {
 	if len(data) == 0 {
 		return "", nil
 	}
 
-	minimum, maximum, sum, average, err := getStats(data)
+	g, err := layout(data, shared, config)
 	if err != nil {
 		return "", err
 	}
 
-	ticks, separator := getTicks(minimum == maximum, config)
+	return concatenateParts(g.ticks, g.styles, g.summary, g.separator, config), nil
+}
+
+// graph is a sparkline before it is written out, with the tick, level and style of every point
+type graph struct {
+	ticks     []rune
+	levels    []int // index of every tick in the pool, from 0 (lowest) to 7 (highest)
+	styles    []tickStyle
+	summary   summary
+	separator string
+}
+
+// flatLevel is the level of every tick when all the values are the same
+const flatLevel = 4
+
+func layout(data []int, shared *scale, config *Config) (*graph, error) {
+	minimum, maximum, sum, average, err := getStats(data)
+	if err != nil {
+		return nil, err
+	}
+	stats := summary{minimum: minimum, maximum: maximum, sum: sum, average: average}
+	if config.ShowTrend {
+		trend, err := LinearTrend(data)
+		if err != nil {
+			return nil, err
+		}
+		stats.trend = &trend
+	}
+
+	bounds := scale{low: minimum, high: maximum}
+	if shared != nil {
+		bounds = *shared
+	}
+
+	pool, separator := getTicks(config)
 
-	divisor := float64(maximum - minimum)
-	factor := len(ticks) - 1
+	divisor := float64(bounds.high - bounds.low)
+	factor := len(pool) - 1
 
 	sparklines := make([]rune, len(data))
+	levels := make([]int, len(data))
+	styles := make([]tickStyle, len(data))
 	for i, n := range data {
 		if divisor == 0 {
-			sparklines[i] = ticks[0]
+			levels[i] = flatLevel
 		} else {
-			sparklines[i] = ticks[int(float64((n-minimum)*factor)/divisor)]
+			levels[i] = int(float64((n-bounds.low)*factor) / divisor)
+		}
+		sparklines[i] = pool[levels[i]]
+		styles[i].fgColor = getFgColor(n, bounds.low, bounds.high, config)
+
+		switch level := getLevel(float64(n), config); level {
+		case levelWarn:
+			stats.warnings++
+			styles[i].fgColor = getLevelColor(level, config)
+		case levelCrit:
+			stats.criticals++
+			styles[i].fgColor = getLevelColor(level, config)
 		}
 	}
 
+	// highlights are applied in order, so the last point wins when it is also the minimum or maximum
+	styles[slices.Index(data, minimum)].highlight(config.MinStyle)
+	styles[slices.Index(data, maximum)].highlight(config.MaxStyle)
+	styles[len(data)-1].highlight(config.LastStyle)
+
 	if config.Reverse {
 		slices.Reverse(sparklines)
+		slices.Reverse(levels)
+		slices.Reverse(styles)
 	}
 
-	return concatenateParts(sparklines, minimum, maximum, sum, average, separator, config), nil
+	return &graph{ticks: sparklines, levels: levels, styles: styles, summary: stats, separator: separator}, nil
+}
+
+type summary struct {
+	minimum   int
+	maximum   int
+	sum       int
+	average   float64
+	warnings  int
+	criticals int
+	edges     []float64 // edges of the bins of a histogram
+	trend     *Trend
 }
 
 func getStats(data []int) (int, int, int, float64, error) {
@@ -52,10 +199,10 @@ func getStats(data []int) (int, int, int, float64, error) {
 		}
 		// Check for overflow before adding
 		if sum > 0 && data[i] > 0 && sum > math.MaxInt-data[i] {
-			return 0, 0, 0, 0, fmt.Errorf("numbers are too large, sum would overflow")
+			return 0, 0, 0, 0, &StatsError{Index: i, Value: data[i], Err: ErrOverflow}
 		}
 		if sum < 0 && data[i] < 0 && sum < math.MinInt-data[i] {
-			return 0, 0, 0, 0, fmt.Errorf("numbers are too large, sum would underflow")
+			return 0, 0, 0, 0, &StatsError{Index: i, Value: data[i], Err: ErrUnderflow}
 		}
 		sum += data[i]
 	}
@@ -65,71 +212,115 @@ func getStats(data []int) (int, int, int, float64, error) {
 	return minimum, maximum, sum, average, nil
 }
 
-func getTicks(sameMinMax bool, config *Config) ([]rune, string) {
-	var pool [8]rune
-	var separator string
+func getTicks(config *Config) ([]rune, string) {
 	if config.Vertical {
-		pool = [8]rune{'▏', '▎', '▍', '▌', '▋', '▊', '▉', '█'}
-		separator = "\n"
-	} else {
-		pool = [8]rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
-		separator = ""
+		return []rune{'▏', '▎', '▍', '▌', '▋', '▊', '▉', '█'}, "\n"
+	}
+	return []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}, ""
+}
+
+func getFgColor(n, minimum, maximum int, config *Config) string {
+	if len(config.Gradient) == 0 {
+		return config.FgColor
 	}
 
-	var ticks []rune
-	if sameMinMax {
-		ticks = pool[4:6]
-	} else {
-		ticks = pool[:]
+	if minimum == maximum {
+		return config.Gradient[0]
+	}
+
+	// each stop covers an equal share of the value range
+	stops := len(config.Gradient)
+	index := int((float64(n) - float64(minimum)) * float64(stops) / (float64(maximum) - float64(minimum)))
+	if index >= stops {
+		index = stops - 1
+	}
+	return config.Gradient[index]
+}
+
+type tickStyle struct {
+	fgColor    string
+	attributes []string
+}
+
+func (s *tickStyle) highlight(style []string) {
+	for _, name := range style {
+		if _, isAttribute := AttributeMap[name]; isAttribute {
+			s.attributes = append(s.attributes, name)
+		} else {
+			s.fgColor = name
+		}
 	}
-	return ticks, separator
 }
 
-func getPrefixAndSuffix(config *Config) (string, string) {
-	if config.BgColor == "" && config.FgColor == "" {
+func getPrefixAndSuffix(bgColor string, style tickStyle) (string, string) {
+	if bgColor == "" && style.fgColor == "" && len(style.attributes) == 0 {
 		return "", ""
 	}
 
-	prefix := "\033["
-	if config.BgColor != "" {
-		prefix += strconv.Itoa(40 + ColorMap[config.BgColor])
+	var codes []string
+	for _, attribute := range style.attributes {
+		codes = append(codes, strconv.Itoa(AttributeMap[attribute]))
 	}
 
-	if config.FgColor != "" {
-		if config.BgColor != "" {
-			prefix += ";"
-		}
-		prefix += strconv.Itoa(30 + ColorMap[config.FgColor])
+	if bgColor != "" {
+		codes = append(codes, strconv.Itoa(40+ColorMap[bgColor]))
+	}
+
+	if style.fgColor != "" {
+		codes = append(codes, strconv.Itoa(30+ColorMap[style.fgColor]))
 	}
-	prefix += "m"
+
+	prefix := "\033[" + strings.Join(codes, ";") + "m"
 
 	suffix := "\033[0m"
 
 	return prefix, suffix
 }
 
-func concatenateParts(sparklines []rune, minimum, maximum, sum int, average float64, separator string, config *Config) string {
+func concatenateParts(sparklines []rune, styles []tickStyle, summary summary, separator string, config *Config) string {
 	var parts []string
 
-	prefix, suffix := getPrefixAndSuffix(config)
+	colored := config.colorEnabled()
 	finalSparklines := make([]string, len(sparklines))
 	for i, r := range sparklines {
+		if !colored {
+			finalSparklines[i] = string(r)
+			continue
+		}
+		prefix, suffix := getPrefixAndSuffix(config.BgColor, styles[i])
 		finalSparklines[i] = fmt.Sprintf("%s%c%s", prefix, r, suffix)
 	}
 	parts = append(parts, strings.Join(finalSparklines, separator))
 
-	if config.ShowSum || config.ShowStats {
+	showBreaches := config.ShowBreaches && (config.Warn != nil || config.Crit != nil)
+	if config.ShowSum || config.ShowStats || showBreaches || summary.trend != nil {
 		parts = append(parts, " (")
 
 		var subParts []string
 		if config.ShowSum {
-			subParts = append(subParts, fmt.Sprintf("sum:%d", sum))
+			subParts = append(subParts, fmt.Sprintf("sum:%d", summary.sum))
 		}
 
 		if config.ShowStats {
-			subParts = append(subParts, fmt.Sprintf("min:%d", minimum))
-			subParts = append(subParts, fmt.Sprintf("max:%d", maximum))
-			subParts = append(subParts, fmt.Sprintf("avg:%.2f", average))
+			subParts = append(subParts, fmt.Sprintf("min:%d", summary.minimum))
+			subParts = append(subParts, fmt.Sprintf("max:%d", summary.maximum))
+			subParts = append(subParts, fmt.Sprintf("avg:%.2f", summary.average))
+			if len(summary.edges) > 0 {
+				subParts = append(subParts, fmt.Sprintf("edges:%s", formatEdges(summary.edges)))
+			}
+		}
+
+		if summary.trend != nil {
+			subParts = append(subParts, fmt.Sprintf("trend:%s", summary.trend))
+		}
+
+		if showBreaches {
+			if config.Warn != nil {
+				subParts = append(subParts, fmt.Sprintf("warn:%d", summary.warnings))
+			}
+			if config.Crit != nil {
+				subParts = append(subParts, fmt.Sprintf("crit:%d", summary.criticals))
+			}
 		}
 
 		parts = append(parts, strings.Join(subParts, " "))
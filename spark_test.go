@@ -143,6 +143,64 @@ var combinedTestCases = []struct {
 	{"all flags combined", []int{1, 2, 3, 4, 5}, "blue", "red", true, true, true, true, "\033[44;31m█\033[0m\n\033[44;31m▊\033[0m\n\033[44;31m▌\033[0m\n\033[44;31m▎\033[0m\n\033[44;31m▏\033[0m (sum:15 min:1 max:5 avg:3.00)"},
 }
 
+var gradientTestCases = []struct {
+	name     string
+	args     []int
+	bgColor  string
+	fgColor  string
+	gradient []string
+	reverse  bool
+	expected string
+}{
+	{"two stops", []int{1, 2, 3, 4}, "", "", []string{"green", "red"}, false, "\033[32m▁\033[0m\033[32m▃\033[0m\033[31m▅\033[0m\033[31m█\033[0m"},
+	{"three stops", []int{1, 5, 9}, "", "", []string{"green", "yellow", "red"}, false, "\033[32m▁\033[0m\033[33m▄\033[0m\033[31m█\033[0m"},
+	{"gradient overrides foreground", []int{1, 9}, "", "blue", []string{"green", "red"}, false, "\033[32m▁\033[0m\033[31m█\033[0m"},
+	{"gradient with background", []int{1, 9}, "blue", "", []string{"green", "red"}, false, "\033[44;32m▁\033[0m\033[44;31m█\033[0m"},
+	{"same numbers use first stop", []int{3, 3}, "", "", []string{"green", "red"}, false, "\033[32m▅\033[0m\033[32m▅\033[0m"},
+	{"reverse keeps colors with values", []int{1, 5, 9}, "", "", []string{"green", "yellow", "red"}, true, "\033[31m█\033[0m\033[33m▄\033[0m\033[32m▁\033[0m"},
+}
+
+var thresholdTestCases = []struct {
+	name          string
+	args          []int
+	fgColor       string
+	warn          *int
+	crit          *int
+	thresholdMode string
+	showBreaches  bool
+	expected      string
+}{
+	{"no thresholds", []int{10, 75, 95}, "", nil, nil, "", false, "▁▆█"},
+	{"warn and crit above", []int{10, 75, 95}, "", intPtr(70), intPtr(90), "", false, "▁\033[33m▆\033[0m\033[31m█\033[0m"},
+	{"thresholds are inclusive", []int{10, 70, 90}, "", intPtr(70), intPtr(90), "", false, "▁\033[33m▆\033[0m\033[31m█\033[0m"},
+	{"other ticks keep foreground", []int{10, 75, 95}, "green", intPtr(70), intPtr(90), "", false, "\033[32m▁\033[0m\033[33m▆\033[0m\033[31m█\033[0m"},
+	{"crit only", []int{10, 75, 95}, "", nil, intPtr(90), "", false, "▁▆\033[31m█\033[0m"},
+	{"warn and crit below", []int{80, 15, 5}, "", intPtr(20), intPtr(10), "below", false, "█\033[33m▁\033[0m\033[31m▁\033[0m"},
+	{"breaches count", []int{10, 75, 95, 99}, "", intPtr(70), intPtr(90), "", true, "▁\033[33m▆\033[0m\033[31m▇\033[0m\033[31m█\033[0m (warn:1 crit:2)"},
+	{"breaches without thresholds", []int{1, 2}, "", nil, nil, "", true, "▁█"},
+}
+
+var highlightTestCases = []struct {
+	name      string
+	args      []int
+	bgColor   string
+	fgColor   string
+	minStyle  []string
+	maxStyle  []string
+	lastStyle []string
+	reverse   bool
+	vertical  bool
+	expected  string
+}{
+	{"min, max and last", []int{3, 1, 5, 2}, "", "", []string{"blue"}, []string{"red", "bold"}, []string{"underline"}, false, false, "▄\033[34m▁\033[0m\033[1;31m█\033[0m\033[4m▂\033[0m"},
+	{"highlight over foreground", []int{3, 1, 5, 2}, "", "green", nil, []string{"bold"}, nil, false, false, "\033[32m▄\033[0m\033[32m▁\033[0m\033[1;32m█\033[0m\033[32m▂\033[0m"},
+	{"highlight with background", []int{1, 5}, "blue", "", nil, []string{"red", "bold"}, nil, false, false, "\033[44m▁\033[0m\033[1;44;31m█\033[0m"},
+	{"last is also the maximum", []int{1, 5}, "", "", nil, []string{"red"}, []string{"green", "underline"}, false, false, "▁\033[4;32m█\033[0m"},
+	{"first occurrence is marked", []int{5, 1, 5}, "", "", nil, []string{"red"}, nil, false, false, "\033[31m█\033[0m▁█"},
+	{"highlights with reverse", []int{3, 1, 5, 2}, "", "", []string{"blue"}, []string{"red", "bold"}, []string{"underline"}, true, false, "\033[4m▂\033[0m\033[1;31m█\033[0m\033[34m▁\033[0m▄"},
+	{"highlights with vertical", []int{3, 1, 5, 2}, "", "", []string{"blue"}, []string{"red", "bold"}, []string{"underline"}, false, true, "▌\n\033[34m▏\033[0m\n\033[1;31m█\033[0m\n\033[4m▎\033[0m"},
+}
+
 func TestSpark(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -235,6 +293,147 @@ func TestSparkCombined(t *testing.T) {
 	}
 }
 
+func TestSparkGradient(t *testing.T) {
+	for _, tc := range gradientTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &Config{
+				BgColor:  tc.bgColor,
+				FgColor:  tc.fgColor,
+				Gradient: tc.gradient,
+				Reverse:  tc.reverse,
+			}
+			actual, err := Spark(tc.args, config)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if actual != tc.expected {
+				t.Errorf("got '%s', want '%s'", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSparkThresholds(t *testing.T) {
+	for _, tc := range thresholdTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &Config{
+				FgColor:       tc.fgColor,
+				Warn:          tc.warn,
+				Crit:          tc.crit,
+				ThresholdMode: tc.thresholdMode,
+				ShowBreaches:  tc.showBreaches,
+			}
+			actual, err := Spark(tc.args, config)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if actual != tc.expected {
+				t.Errorf("got '%s', want '%s'", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSparkHighlights(t *testing.T) {
+	for _, tc := range highlightTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &Config{
+				BgColor:   tc.bgColor,
+				FgColor:   tc.fgColor,
+				MinStyle:  tc.minStyle,
+				MaxStyle:  tc.maxStyle,
+				LastStyle: tc.lastStyle,
+				Reverse:   tc.reverse,
+				Vertical:  tc.vertical,
+			}
+			actual, err := Spark(tc.args, config)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if actual != tc.expected {
+				t.Errorf("got '%s', want '%s'", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSparkColorMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		colorMode string
+		expected  string
+	}{
+		{"default mode colors", "", "\033[31m▁\033[0m\033[1;31m█\033[0m"},
+		{"always colors", "always", "\033[31m▁\033[0m\033[1;31m█\033[0m"},
+		{"never colors", "never", "▁█"},
+		{"auto colors nothing without a known output", "auto", "▁█"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &Config{
+				FgColor:   "red",
+				MaxStyle:  []string{"bold"},
+				ColorMode: tc.colorMode,
+			}
+			actual, err := Spark([]int{1, 2}, config)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if actual != tc.expected {
+				t.Errorf("got '%s', want '%s'", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSparkGaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []int
+		gaps     []bool
+		config   *Config
+		expected string
+		errorMsg string
+	}{
+		{"no gaps", []int{1, 2, 3}, []bool{false, false, false}, &Config{}, "▁▄█", ""},
+		{"gaps left out of the scale", []int{1, 100, 3}, []bool{false, true, false}, &Config{ShowStats: true}, "▁ █ (min:1 max:3 avg:2.00)", ""},
+		{"reversed", []int{1, 0, 3, 5}, []bool{false, true, false, false}, &Config{Reverse: true}, "█▄ ▁", ""},
+		{"vertical", []int{1, 0, 3}, []bool{false, true, false}, &Config{Vertical: true}, "▏\n \n█", ""},
+		{"gaps are not colored", []int{1, 0, 3}, []bool{false, true, false}, &Config{FgColor: "red"}, "\033[31m▁\033[0m \033[31m█\033[0m", ""},
+		{"only gaps", []int{0, 0}, []bool{true, true}, &Config{}, "", ErrNoData.Error()},
+		{"mismatched gaps", []int{1, 2}, []bool{false}, &Config{}, "", "got 1 gaps for 2 points"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := SparkGaps(tc.data, tc.gaps, tc.config)
+			if tc.errorMsg != "" {
+				if err == nil || err.Error() != tc.errorMsg {
+					t.Errorf("expected error message '%s', got '%v'", tc.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if actual != tc.expected {
+				t.Errorf("got '%s', want '%s'", actual, tc.expected)
+			}
+		})
+	}
+}
+
 func BenchmarkSparkWithoutColors(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _ = Spark([]int{1, 5, 22, 13, 5}, &Config{
@@ -0,0 +1,268 @@
+package spark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StatsDCounter = "c"
+	StatsDGauge   = "g"
+	StatsDTimer   = "ms"
+)
+
+var (
+	StatsDTypeNames = map[string]string{
+		StatsDCounter: "counter",
+		StatsDGauge:   "gauge",
+		StatsDTimer:   "timer",
+	}
+)
+
+// Metric is one StatsD sample, as in name:value|type|@rate
+type Metric struct {
+	Name  string
+	Type  string
+	Value float64
+	Rate  float64 // sample rate of counters, 1 when not given
+	Delta bool    // whether a gauge value is relative, when it starts with a sign
+}
+
+// ParseStatsD parses one StatsD line. Histograms (h) are read as timers, tags are ignored and sets
+// are not supported.
+func ParseStatsD(line string) (Metric, error) {
+	name, rest, found := strings.Cut(line, ":")
+	if !found || name == "" {
+		return Metric{}, fmt.Errorf("%w, expected name:value|type: %s", ErrInvalidMetric, line)
+	}
+
+	parts := strings.Split(rest, "|")
+	if len(parts) < 2 {
+		return Metric{}, fmt.Errorf("%w, expected name:value|type: %s", ErrInvalidMetric, line)
+	}
+
+	metric := Metric{Name: name, Type: parts[1], Rate: 1}
+	if metric.Type == "h" {
+		metric.Type = StatsDTimer
+	}
+	if _, ok := StatsDTypeNames[metric.Type]; !ok {
+		return Metric{}, fmt.Errorf("%w, unsupported type %s: %s", ErrInvalidMetric, parts[1], line)
+	}
+
+	value, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return Metric{}, fmt.Errorf("%w, invalid value %s: %s", ErrInvalidMetric, parts[0], line)
+	}
+	metric.Value = value
+	metric.Delta = metric.Type == StatsDGauge && (parts[0][0] == '+' || parts[0][0] == '-')
+
+	for _, part := range parts[2:] {
+		if !strings.HasPrefix(part, "@") {
+			continue
+		}
+		rate, err := strconv.ParseFloat(part[1:], 64)
+		if err != nil || rate <= 0 || rate > 1 {
+			return Metric{}, fmt.Errorf("%w, invalid sample rate %s: %s", ErrInvalidMetric, part[1:], line)
+		}
+		metric.Rate = rate
+	}
+
+	return metric, nil
+}
+
+// Buckets aggregates metrics per name into time buckets and keeps the last size buckets of each
+// one. When a bucket is closed, counters hold the sum of their samples (scaled by the sample
+// rate), gauges their last value and timers the mean of their samples, or 0 without any.
+type Buckets struct {
+	size    int
+	names   []string
+	types   map[string]string
+	windows map[string]*Window
+	sums    map[string]float64
+	counts  map[string]int
+	gauges  map[string]float64
+}
+
+func NewBuckets(size int) (*Buckets, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("window size must be at least 1: %d", size)
+	}
+
+	return &Buckets{
+		size:    size,
+		types:   make(map[string]string),
+		windows: make(map[string]*Window),
+		sums:    make(map[string]float64),
+		counts:  make(map[string]int),
+		gauges:  make(map[string]float64),
+	}, nil
+}
+
+// Add adds metric to the current bucket of its name, which must always be of the same type
+func (b *Buckets) Add(metric Metric) error {
+	if t, ok := b.types[metric.Name]; !ok {
+		b.names = append(b.names, metric.Name)
+		b.types[metric.Name] = metric.Type
+		b.windows[metric.Name], _ = NewWindow(b.size)
+	} else if t != metric.Type {
+		return fmt.Errorf("%w, %s is a %s, not a %s", ErrInvalidMetric, metric.Name, StatsDTypeNames[t], StatsDTypeNames[metric.Type])
+	}
+
+	switch metric.Type {
+	case StatsDCounter:
+		b.sums[metric.Name] += metric.Value / metric.Rate
+	case StatsDGauge:
+		if metric.Delta {
+			b.gauges[metric.Name] += metric.Value
+		} else {
+			b.gauges[metric.Name] = metric.Value
+		}
+	case StatsDTimer:
+		b.sums[metric.Name] += metric.Value
+		b.counts[metric.Name]++
+	}
+	return nil
+}
+
+// Flush closes the current bucket of every metric and starts new ones
+func (b *Buckets) Flush() error {
+	for _, name := range b.names {
+		var value float64
+		switch b.types[name] {
+		case StatsDCounter:
+			value = b.sums[name]
+		case StatsDGauge:
+			value = b.gauges[name]
+		case StatsDTimer:
+			if b.counts[name] > 0 {
+				value = b.sums[name] / float64(b.counts[name])
+			}
+		}
+
+		n, err := floatToInt(math.Round(value))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		b.windows[name].Push(n)
+
+		b.sums[name], b.counts[name] = 0, 0
+	}
+	return nil
+}
+
+// Series returns the closed buckets of every metric, in first seen order
+func (b *Buckets) Series() []Series {
+	series := make([]Series, 0, len(b.names))
+	for _, name := range b.names {
+		if data := b.windows[name].Values(); len(data) > 0 {
+			series = append(series, Series{Label: name, Data: data})
+		}
+	}
+	return series
+}
+
+// Listen reads StatsD packets from conn, aggregates their metrics into buckets of interval and,
+// after each one, redraws one sparkline per metric with its last size buckets in place on w, until
+// ctx is done. Invalid metrics stop it, unless config.SkipInvalid is set, in which case they are
+// counted and dropped.
+func Listen(ctx context.Context, conn net.PacketConn, interval time.Duration, w io.Writer, size int, config *Config) (int, error) {
+	if interval <= 0 {
+		return 0, fmt.Errorf("interval must be positive: %s", interval)
+	}
+	if config.Vertical {
+		return 0, fmt.Errorf("vertical graphs cannot be redrawn in place")
+	}
+
+	buckets, err := NewBuckets(size)
+	if err != nil {
+		return 0, err
+	}
+
+	// rates are per second of the buckets drawn
+	bucketed := *config
+	bucketed.Interval = interval
+	config = &bucketed
+
+	packets := make(chan string)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	go readPackets(conn, packets, readErr, done)
+	defer close(done)
+	// unblock the reader once done, it exits on the read error
+	defer conn.SetReadDeadline(time.Now())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	skipped, drawn := 0, 0
+	for {
+		select {
+		case <-ctx.Done():
+			return skipped, finishRedraw(w, drawn > 0, nil)
+		case err := <-readErr:
+			return skipped, finishRedraw(w, drawn > 0, err)
+		case packet := <-packets:
+			for _, line := range strings.Split(packet, "\n") {
+				if line = strings.TrimSpace(line); line == "" {
+					continue
+				}
+				metric, err := ParseStatsD(line)
+				if err == nil {
+					err = buckets.Add(metric)
+				}
+				if err != nil && config.SkipInvalid {
+					skipped++
+					continue
+				}
+				if err != nil {
+					return skipped, finishRedraw(w, drawn > 0, err)
+				}
+			}
+		case <-ticker.C:
+			if err := buckets.Flush(); err != nil {
+				return skipped, finishRedraw(w, drawn > 0, err)
+			}
+			series := buckets.Series()
+			sortSeries(series, config)
+			if len(series) == 0 {
+				continue
+			}
+
+			sparks, err := SparkSeries(series, config)
+			if err != nil {
+				return skipped, finishRedraw(w, drawn > 0, err)
+			}
+			if drawn, err = redrawLines(w, sparks, drawn); err != nil {
+				return skipped, finishRedraw(w, drawn > 0, err)
+			}
+		}
+	}
+}
+
+// readPackets sends every packet read from conn to packets until done is closed or reading fails
+func readPackets(conn net.PacketConn, packets chan<- string, readErr chan<- error, done <-chan struct{}) {
+	buf := make([]byte, 65535)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrDeadlineExceeded) && !errors.Is(err, net.ErrClosed) {
+				readErr <- err
+			}
+			return
+		}
+
+		select {
+		case packets <- string(buf[:n]):
+		case <-done:
+			return
+		}
+	}
+}
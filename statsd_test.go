@@ -0,0 +1,208 @@
+package spark
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseStatsD(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Metric
+	}{
+		{"hits:1|c", Metric{Name: "hits", Type: StatsDCounter, Value: 1, Rate: 1}},
+		{"hits:3|c|@0.5", Metric{Name: "hits", Type: StatsDCounter, Value: 3, Rate: 0.5}},
+		{"hits:3|c|#env:prod|@0.1", Metric{Name: "hits", Type: StatsDCounter, Value: 3, Rate: 0.1}},
+		{"cpu:42.5|g", Metric{Name: "cpu", Type: StatsDGauge, Value: 42.5, Rate: 1}},
+		{"cpu:-5|g", Metric{Name: "cpu", Type: StatsDGauge, Value: -5, Rate: 1, Delta: true}},
+		{"cpu:+5|g", Metric{Name: "cpu", Type: StatsDGauge, Value: 5, Rate: 1, Delta: true}},
+		{"latency:320|ms", Metric{Name: "latency", Type: StatsDTimer, Value: 320, Rate: 1}},
+		{"size:12|h", Metric{Name: "size", Type: StatsDTimer, Value: 12, Rate: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			actual, err := ParseStatsD(tt.line)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tt.expected {
+				t.Errorf("got %+v, want %+v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseStatsDErrors(t *testing.T) {
+	tests := []struct {
+		line     string
+		errorMsg string
+	}{
+		{"hits", "invalid metric, expected name:value|type: hits"},
+		{":1|c", "invalid metric, expected name:value|type: :1|c"},
+		{"hits:1", "invalid metric, expected name:value|type: hits:1"},
+		{"users:bob|s", "invalid metric, unsupported type s: users:bob|s"},
+		{"hits:abc|c", "invalid metric, invalid value abc: hits:abc|c"},
+		{"hits:NaN|c", "invalid metric, invalid value NaN: hits:NaN|c"},
+		{"hits:1|c|@2", "invalid metric, invalid sample rate 2: hits:1|c|@2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			_, err := ParseStatsD(tt.line)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if err.Error() != tt.errorMsg {
+				t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	buckets, err := NewBuckets(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	add := func(lines ...string) {
+		for _, line := range lines {
+			metric, err := ParseStatsD(line)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := buckets.Add(metric); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := buckets.Flush(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	add("hits:1|c", "hits:2|c|@0.5", "cpu:40|g", "latency:100|ms", "latency:201|ms")
+	add("cpu:+5|g")
+	add("hits:1|c", "cpu:-15|g", "latency:10|ms")
+	add("cpu:80|g")
+
+	expected := []Series{
+		{Label: "hits", Data: []int{0, 1, 0}},
+		{Label: "cpu", Data: []int{45, 30, 80}},
+		{Label: "latency", Data: []int{0, 10, 0}},
+	}
+	if actual := buckets.Series(); !seriesEqual(actual, expected) {
+		t.Errorf("got %v, want %v", actual, expected)
+	}
+
+	metric, _ := ParseStatsD("cpu:1|c")
+	if err := buckets.Add(metric); err == nil || err.Error() != "invalid metric, cpu is a gauge, not a counter" {
+		t.Errorf("expected type mismatch error, got %v", err)
+	}
+
+	if _, err := NewBuckets(0); err == nil || err.Error() != "window size must be at least 1: 0" {
+		t.Errorf("expected window size error, got %v", err)
+	}
+}
+
+// syncBuffer is a bytes.Buffer that can be written by Listen while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestListen(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	sender, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sender.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out syncBuffer
+	result := make(chan error, 1)
+	go func() {
+		_, err := Listen(ctx, conn, 20*time.Millisecond, &out, 5, &Config{SortSeries: true})
+		result <- err
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(out.String(), "load ▅") && time.Now().Before(deadline) {
+		_, _ = sender.Write([]byte("load:3|g\nhits:1|c"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	if err := <-result; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := out.String()
+	if !strings.Contains(output, "hits ") || !strings.Contains(output, "\nload ▅") {
+		t.Errorf("expected both metrics sorted by name, got %q", output)
+	}
+	if !strings.HasSuffix(output, "\n") {
+		t.Errorf("expected output to end with a newline, got %q", output)
+	}
+}
+
+func TestListenErrors(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := Listen(context.Background(), conn, 0, &bytes.Buffer{}, 5, &Config{}); err == nil || err.Error() != "interval must be positive: 0s" {
+		t.Errorf("expected interval error, got %v", err)
+	}
+	if _, err := Listen(context.Background(), conn, time.Second, &bytes.Buffer{}, 5, &Config{Vertical: true}); err == nil {
+		t.Errorf("expected vertical error, got none")
+	}
+
+	sender, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sender.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := Listen(context.Background(), conn, time.Second, &bytes.Buffer{}, 5, &Config{})
+		result <- err
+	}()
+	_, _ = sender.Write([]byte("hits:abc|c"))
+
+	select {
+	case err := <-result:
+		if err == nil || err.Error() != "invalid metric, invalid value abc: hits:abc|c" {
+			t.Errorf("expected invalid metric error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected Listen to stop on the invalid metric")
+	}
+}
@@ -0,0 +1,80 @@
+package spark
+
+import "fmt"
+
+const (
+	ThresholdAbove = "above"
+	ThresholdBelow = "below"
+)
+
+const (
+	levelOk = iota
+	levelWarn
+	levelCrit
+)
+
+func ValidateThresholdMode(mode string) error {
+	if mode == "" || mode == ThresholdAbove || mode == ThresholdBelow {
+		return nil
+	}
+
+	return fmt.Errorf("invalid threshold mode: %s", mode)
+}
+
+func validateThresholds(config *Config) error {
+	if err := ValidateThresholdMode(config.ThresholdMode); err != nil {
+		return err
+	}
+	if err := ValidateColor(config.WarnColor); err != nil {
+		return err
+	}
+	if err := ValidateColor(config.CritColor); err != nil {
+		return err
+	}
+
+	if config.Warn == nil || config.Crit == nil {
+		return nil
+	}
+
+	if config.ThresholdMode == ThresholdBelow && *config.Warn < *config.Crit {
+		return fmt.Errorf("warning threshold %d must not be below critical threshold %d", *config.Warn, *config.Crit)
+	}
+	if config.ThresholdMode != ThresholdBelow && *config.Warn > *config.Crit {
+		return fmt.Errorf("warning threshold %d must not be above critical threshold %d", *config.Warn, *config.Crit)
+	}
+
+	return nil
+}
+
+func breaches(n float64, threshold int, config *Config) bool {
+	if config.ThresholdMode == ThresholdBelow {
+		return n <= float64(threshold)
+	}
+	return n >= float64(threshold)
+}
+
+func getLevel(n float64, config *Config) int {
+	if config.Crit != nil && breaches(n, *config.Crit, config) {
+		return levelCrit
+	}
+	if config.Warn != nil && breaches(n, *config.Warn, config) {
+		return levelWarn
+	}
+	return levelOk
+}
+
+func getLevelColor(level int, config *Config) string {
+	switch level {
+	case levelCrit:
+		if config.CritColor != "" {
+			return config.CritColor
+		}
+		return "red"
+	case levelWarn:
+		if config.WarnColor != "" {
+			return config.WarnColor
+		}
+		return "yellow"
+	}
+	return ""
+}
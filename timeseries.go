@@ -0,0 +1,312 @@
+package spark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultAggregation is the value of a bucket when no aggregate is given
+const defaultAggregation = "avg"
+
+// maxBuckets bounds the number of buckets, which a narrow bucket over a long time span could blow up
+const maxBuckets = 1_000_000
+
+// Point is a value observed at a time
+type Point struct {
+	Time  time.Time
+	Value int
+}
+
+// ParsePoints parses timestamped lines from args, or from stdin when there are no args and stdin is
+// not nil. Timestamps are read from the config.TimeField column (starting at 1) in the
+// config.TimeLayout layout, or else as RFC 3339 or Unix epoch timestamps. A layout with spaces
+// spans as many columns. Values are read from config.ValueColumn, which defaults to the first
+// column that is not part of the timestamp.
+func ParsePoints(args []string, stdin io.Reader, config *Config) ([]Point, int, error) {
+	if len(args) == 0 && stdin != nil {
+		points, skipped, err := parsePoints(stdin, config, false)
+		return points, skipped, setSource(err, "<stdin>")
+	}
+
+	points, skipped, err := parsePoints(strings.NewReader(strings.Join(args, "\n")), config, false)
+	return points, skipped, setSource(err, "<args>")
+}
+
+// ParseEvents is ParsePoints for lines that are events, such as log lines, with a timestamp but
+// no value. It returns the time of every event.
+func ParseEvents(args []string, stdin io.Reader, config *Config) ([]time.Time, int, error) {
+	var points []Point
+	var skipped int
+	var err error
+	if len(args) == 0 && stdin != nil {
+		points, skipped, err = parsePoints(stdin, config, true)
+		err = setSource(err, "<stdin>")
+	} else {
+		points, skipped, err = parsePoints(strings.NewReader(strings.Join(args, "\n")), config, true)
+		err = setSource(err, "<args>")
+	}
+
+	times := make([]time.Time, len(points))
+	for i, p := range points {
+		times[i] = p.Time
+	}
+	return times, skipped, err
+}
+
+// CountEvents counts the events at times per bucket of width, like BucketPoints, with 0 for the
+// buckets without any event
+func CountEvents(times []time.Time, width time.Duration) ([]int, error) {
+	points := make([]Point, len(times))
+	for i, t := range times {
+		points[i] = Point{Time: t, Value: 1}
+	}
+
+	counts, _, err := BucketPoints(points, width, "count")
+	return counts, err
+}
+
+// parsePoints reads timestamped values, or events whose value is always 1
+func parsePoints(r io.Reader, config *Config, events bool) ([]Point, int, error) {
+	var points []Point
+	skipped := 0
+
+	timeColumn, timeColumns := config.timeField(), config.timeColumns()
+	valueColumn := config.valueColumn()
+	if events {
+		valueColumn = 0
+	}
+
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadString('\n')
+		if line == "" && err == io.EOF {
+			break
+		}
+		if line == "" && err != nil {
+			return nil, skipped, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		tokens := fields(line)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		if len(tokens) < timeColumn+timeColumns-1 || len(tokens) < valueColumn {
+			if config.SkipInvalid {
+				skipped++
+				continue
+			}
+			return nil, skipped, &ParseError{Line: lineNo, Column: tokens[0].column, Token: line, Err: ErrColumnCount}
+		}
+
+		timestamp := tokens[timeColumn-1]
+		for _, t := range tokens[timeColumn : timeColumn+timeColumns-1] {
+			timestamp.token += " " + t.token
+		}
+
+		t, err := ParseTime(timestamp.token, config.TimeLayout)
+		if err != nil && config.SkipInvalid {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, skipped, &ParseError{Line: lineNo, Column: timestamp.column, Token: timestamp.token, Err: err}
+		}
+
+		if events {
+			points = append(points, Point{Time: t, Value: 1})
+			continue
+		}
+
+		value := tokens[valueColumn-1]
+		n, err := parseNumber(value.token)
+		if err != nil && config.SkipInvalid {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, skipped, &ParseError{Line: lineNo, Column: value.column, Token: value.token, Err: err}
+		}
+
+		points = append(points, Point{Time: t, Value: n})
+	}
+
+	if len(points) == 0 {
+		return nil, skipped, ErrNoData
+	}
+
+	return points, skipped, nil
+}
+
+// ParseTime parses s in layout, or else as RFC 3339 (with or without its time and zone) or as a
+// Unix epoch timestamp, in seconds, milliseconds, microseconds or nanoseconds according to its size
+func ParseTime(s, layout string) (time.Time, error) {
+	if layout != "" {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return time.Time{}, ErrInvalidTime
+		}
+		return t, nil
+	}
+
+	for _, l := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+
+	epoch, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(epoch, 0) || math.IsNaN(epoch) {
+		return time.Time{}, ErrInvalidTime
+	}
+
+	// epochs in seconds stay below 1e11 until the year 5138, so bigger ones are in finer units
+	perSecond := int64(1)
+	switch magnitude := math.Abs(epoch); {
+	case magnitude >= 1e17:
+		perSecond = int64(time.Second)
+	case magnitude >= 1e14:
+		perSecond = int64(time.Second / time.Microsecond)
+	case magnitude >= 1e11:
+		perSecond = int64(time.Second / time.Millisecond)
+	}
+
+	// integers are split exactly, a float64 cannot hold every nanosecond
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n/perSecond, n%perSecond*(int64(time.Second)/perSecond)).UTC(), nil
+	}
+	seconds, fraction := math.Modf(epoch / float64(perSecond))
+	return time.Unix(int64(seconds), int64(fraction*1e9)).UTC(), nil
+}
+
+// ParseDuration is time.ParseDuration with days (d) and weeks (w) too, leading the other units as in 1d12h
+func ParseDuration(s string) (time.Duration, error) {
+	var total time.Duration
+	rest := s
+	for _, unit := range []struct {
+		suffix   string
+		duration time.Duration
+	}{{"w", 7 * 24 * time.Hour}, {"d", 24 * time.Hour}} {
+		number, after, found := strings.Cut(rest, unit.suffix)
+		if !found {
+			continue
+		}
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil || n < 0 || math.IsInf(n, 0) {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		total += time.Duration(n * float64(unit.duration))
+		rest = after
+	}
+
+	if rest == "" && rest != s {
+		return total, nil
+	}
+	d, err := time.ParseDuration(rest)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	return total + d, nil
+}
+
+// BucketPoints sorts points by time into consecutive buckets of width, aligned on multiples of
+// width since the zero time (midnight UTC for days), and returns the aggregate of every bucket
+// rounded to the nearest integer, the average when aggregate is empty. Buckets without any point
+// are marked in gaps.
+func BucketPoints(points []Point, width time.Duration, aggregate string) ([]int, []bool, error) {
+	if width <= 0 {
+		return nil, nil, fmt.Errorf("bucket must be positive: %s", width)
+	}
+	if aggregate == "" {
+		aggregate = defaultAggregation
+	}
+	if err := ValidateAggregate(aggregate); err != nil {
+		return nil, nil, err
+	}
+	if len(points) == 0 {
+		return nil, nil, ErrNoData
+	}
+
+	sorted := slices.Clone(points)
+	slices.SortStableFunc(sorted, func(a, b Point) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	start := sorted[0].Time.Truncate(width)
+	count := sorted[len(sorted)-1].Time.Truncate(width).Sub(start)/width + 1
+	if count > maxBuckets || count < 0 {
+		return nil, nil, fmt.Errorf("too many buckets of %s, use a wider bucket", width)
+	}
+
+	buckets := make([][]int, count)
+	for _, p := range sorted {
+		i := p.Time.Sub(start) / width
+		buckets[i] = append(buckets[i], p.Value)
+	}
+
+	data := make([]int, count)
+	gaps := make([]bool, count)
+	for i, bucket := range buckets {
+		if len(bucket) == 0 {
+			gaps[i] = true
+			continue
+		}
+
+		value, err := Aggregate(bucket, aggregate)
+		if err != nil {
+			return nil, nil, err
+		}
+		if data[i], err = floatToInt(math.Round(value)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return data, gaps, nil
+}
+
+// timeField defaults to the first column
+func (c *Config) timeField() int {
+	if c.TimeField > 0 {
+		return c.TimeField
+	}
+	return 1
+}
+
+// timeColumns is the number of columns a timestamp spans, one unless the layout has spaces
+func (c *Config) timeColumns() int {
+	return max(1, len(strings.Fields(c.TimeLayout)))
+}
+
+func validateBuckets(config *Config) error {
+	if config.Bucket < 0 {
+		return fmt.Errorf("bucket must be positive: %s", config.Bucket)
+	}
+	if config.TimeField < 0 {
+		return fmt.Errorf("invalid time column: %d", config.TimeField)
+	}
+	if config.Bucket == 0 {
+		return nil
+	}
+	if config.SeriesMode != "" || config.GroupBy > 0 || config.Prometheus != "" || config.Influx != "" || config.Graphite != "" {
+		return fmt.Errorf("time buckets cannot be combined with several series")
+	}
+	if config.ValueColumn >= config.timeField() && config.ValueColumn < config.timeField()+config.timeColumns() {
+		return fmt.Errorf("time and value columns must be different: %d", config.ValueColumn)
+	}
+	return ValidateAggregate(config.aggregation())
+}
+
+// aggregation defaults to the average
+func (c *Config) aggregation() string {
+	if c.Aggregation != "" {
+		return c.Aggregation
+	}
+	return defaultAggregation
+}
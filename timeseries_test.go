@@ -0,0 +1,296 @@
+package spark
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		input    string
+		layout   string
+		expected time.Time
+	}{
+		{"2024-05-01T10:00:00Z", "", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{"2024-05-01T12:00:00.5+02:00", "", time.Date(2024, 5, 1, 10, 0, 0, 5e8, time.UTC)},
+		{"2024-05-01T10:00:00", "", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{"2024-05-01", "", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"1714557600", "", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{"1714557600.25", "", time.Date(2024, 5, 1, 10, 0, 0, 25e7, time.UTC)},
+		{"1714557600123", "", time.Date(2024, 5, 1, 10, 0, 0, 123e6, time.UTC)},
+		{"1714557600123456", "", time.Date(2024, 5, 1, 10, 0, 0, 123456e3, time.UTC)},
+		{"1714557600123456789", "", time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)},
+		{"01/May/2024:12:00:00 +0200", "02/Jan/2006:15:04:05 -0700", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := ParseTime(tt.input, tt.layout)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !actual.Equal(tt.expected) {
+				t.Errorf("got %s, want %s", actual, tt.expected)
+			}
+		})
+	}
+
+	for _, input := range []string{"yesterday", "NaN", "2024-13-01", ""} {
+		if _, err := ParseTime(input, ""); err != ErrInvalidTime {
+			t.Errorf("ParseTime(%q) got error %v, want %v", input, err, ErrInvalidTime)
+		}
+	}
+	if _, err := ParseTime("2024-05-01", "15:04"); err != ErrInvalidTime {
+		t.Errorf("got error %v, want %v", err, ErrInvalidTime)
+	}
+}
+
+func TestParsePoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		config      Config
+		expected    []Point
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:   "time then value",
+			input:  "2024-05-01T10:00:00Z 5\n\n1714557660,7\n",
+			config: Config{Bucket: time.Minute},
+			expected: []Point{
+				{Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Value: 5},
+				{Time: time.Date(2024, 5, 1, 10, 1, 0, 0, time.UTC), Value: 7},
+			},
+		},
+		{
+			name:     "value then time",
+			input:    "GET 120 2024-05-01\n",
+			config:   Config{Bucket: time.Hour, TimeField: 3, ValueColumn: 2},
+			expected: []Point{{Time: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Value: 120}},
+		},
+		{
+			name:     "layout with spaces",
+			input:    "2024-05-01 10:00:00 42\n",
+			config:   Config{Bucket: time.Hour, TimeLayout: "2006-01-02 15:04:05"},
+			expected: []Point{{Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Value: 42}},
+		},
+		{
+			name:     "skip invalid lines",
+			input:    "header value\n2024-05-01 1\n2024-05-01 x\n2024-05-02\n",
+			config:   Config{Bucket: time.Hour, SkipInvalid: true},
+			expected: []Point{{Time: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Value: 1}},
+		},
+		{
+			name:        "invalid time",
+			input:       "2024-05-01 1\nyesterday 2\n",
+			config:      Config{Bucket: time.Hour},
+			expectError: true,
+			errorMsg:    "line 2, column 1: invalid time: yesterday",
+		},
+		{
+			name:        "invalid value",
+			input:       "2024-05-01  abc\n",
+			config:      Config{Bucket: time.Hour},
+			expectError: true,
+			errorMsg:    "line 1, column 13: invalid number: abc",
+		},
+		{
+			name:        "missing value",
+			input:       "2024-05-01\n",
+			config:      Config{Bucket: time.Hour},
+			expectError: true,
+			errorMsg:    "line 1, column 1: wrong number of columns: 2024-05-01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, _, err := ParsePoints(nil, strings.NewReader(tt.input), &tt.config)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if len(actual) != len(tt.expected) {
+				t.Fatalf("got %v, want %v", actual, tt.expected)
+			}
+			for i := range actual {
+				if !actual[i].Time.Equal(tt.expected[i].Time) || actual[i].Value != tt.expected[i].Value {
+					t.Errorf("got %v, want %v", actual, tt.expected)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestBucketPoints(t *testing.T) {
+	at := func(minute, second int) time.Time {
+		return time.Date(2024, 5, 1, 10, minute, second, 0, time.UTC)
+	}
+	// out of order on purpose, buckets are sorted by time
+	points := []Point{
+		{Time: at(4, 0), Value: 9},
+		{Time: at(0, 10), Value: 5},
+		{Time: at(0, 50), Value: 8},
+		{Time: at(1, 30), Value: 2},
+	}
+
+	tests := []struct {
+		aggregate string
+		expected  []int
+	}{
+		{"avg", []int{7, 2, 0, 0, 9}},
+		{"", []int{7, 2, 0, 0, 9}},
+		{"sum", []int{13, 2, 0, 0, 9}},
+		{"count", []int{2, 1, 0, 0, 1}},
+		{"first", []int{5, 2, 0, 0, 9}},
+		{"max", []int{8, 2, 0, 0, 9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.aggregate, func(t *testing.T) {
+			data, gaps, err := BucketPoints(points, time.Minute, tt.aggregate)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(data, tt.expected) {
+				t.Errorf("got %v, want %v", data, tt.expected)
+			}
+			if expectedGaps := []bool{false, false, true, true, false}; !slices.Equal(gaps, expectedGaps) {
+				t.Errorf("got gaps %v, want %v", gaps, expectedGaps)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name      string
+		points    []Point
+		width     time.Duration
+		aggregate string
+		errorMsg  string
+	}{
+		{"invalid width", points, 0, "avg", "bucket must be positive: 0s"},
+		{"invalid aggregate", points, time.Minute, "median", "invalid aggregate: median"},
+		{"no points", nil, time.Minute, "avg", ErrNoData.Error()},
+		{"too many buckets", []Point{{Time: at(0, 0)}, {Time: at(0, 0).AddDate(1, 0, 0)}}, time.Millisecond, "avg", "too many buckets of 1ms, use a wider bucket"},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := BucketPoints(tt.points, tt.width, tt.aggregate)
+			if err == nil || err.Error() != tt.errorMsg {
+				t.Errorf("expected error message '%s', got '%v'", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestValidateBuckets(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		errorMsg string
+	}{
+		{"valid", Config{Bucket: time.Minute, Aggregation: "p95", ValueColumn: 2}, ""},
+		{"negative bucket", Config{Bucket: -time.Minute}, "bucket must be positive: -1m0s"},
+		{"invalid time column", Config{TimeField: -1}, "invalid time column: -1"},
+		{"several series", Config{Bucket: time.Minute, SeriesMode: SeriesLines}, "time buckets cannot be combined with several series"},
+		{"value in time columns", Config{Bucket: time.Minute, TimeLayout: "2006-01-02 15:04", ValueColumn: 2}, "time and value columns must be different: 2"},
+		{"invalid aggregate", Config{Bucket: time.Minute, Aggregation: "mode"}, "invalid aggregate: mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.errorMsg {
+				t.Errorf("expected error message '%s', got '%v'", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"1d", 24 * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"1w2d3h", 9*24*time.Hour + 3*time.Hour},
+		{"1d12h30m", 36*time.Hour + 30*time.Minute},
+		{"90s", 90 * time.Second},
+		{"250ms", 250 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		actual, err := ParseDuration(tt.input)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if actual != tt.expected {
+			t.Errorf("ParseDuration(%q) = %s, want %s", tt.input, actual, tt.expected)
+		}
+	}
+
+	for _, input := range []string{"", "d", "1x", "1h2d", "-1d", "1d2"} {
+		if _, err := ParseDuration(input); err == nil || err.Error() != "invalid duration: "+input {
+			t.Errorf("ParseDuration(%q) got error %v, want invalid duration", input, err)
+		}
+	}
+}
+
+func TestCountEvents(t *testing.T) {
+	input := "2024-05-01T10:00:00Z GET /\n" +
+		"2024-05-01T10:00:59Z GET /api\n" +
+		"  at com.example.Main\n" +
+		"2024-05-01T10:03:10Z POST /api\n"
+
+	config := &Config{Bucket: time.Minute, SkipInvalid: true}
+	times, skipped, err := ParseEvents(nil, strings.NewReader(input), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if skipped != 1 {
+		t.Errorf("got %d skipped lines, want 1", skipped)
+	}
+
+	counts, err := CountEvents(times, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []int{2, 0, 0, 1}; !slices.Equal(counts, expected) {
+		t.Errorf("got %v, want %v", counts, expected)
+	}
+
+	config.SkipInvalid = false
+	if _, _, err := ParseEvents(nil, strings.NewReader(input), config); err == nil || err.Error() != "line 3, column 3: invalid time: at" {
+		t.Errorf("expected invalid time error, got %v", err)
+	}
+	if _, err := CountEvents(nil, time.Minute); err != ErrNoData {
+		t.Errorf("got error %v, want %v", err, ErrNoData)
+	}
+}
@@ -0,0 +1,347 @@
+package spark
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TransformSMA       = "sma"
+	TransformEMA       = "ema"
+	TransformMedian    = "median"
+	TransformCumSum    = "cumsum"
+	TransformDiff      = "diff"
+	TransformRate      = "rate"
+	TransformPctChange = "pctchange"
+	TransformNormalize = "normalize"
+)
+
+// Transform turns the points of a graph into other points, possibly fewer
+type Transform func(data []int) ([]int, error)
+
+// ParseTransform parses a transform given as name or name:parameter:
+//   - sma:N, ema:N and median:N smooth over windows of N points
+//   - cumsum, diff and pctchange are the running total, the difference and the percent change
+//     from one point to the next
+//   - rate or rate:interval is the per-second increase of a counter sampled every interval (1s
+//     by default)
+//   - normalize scales the points between 0 and 100
+func ParseTransform(spec string) (Transform, error) {
+	return parseTransform(spec, time.Second)
+}
+
+// parseTransform is ParseTransform with the default interval of rate
+func parseTransform(spec string, interval time.Duration) (Transform, error) {
+	name, param, hasParam := strings.Cut(spec, ":")
+
+	switch name {
+	case TransformSMA, TransformEMA, TransformMedian:
+		window, err := strconv.Atoi(param)
+		if err != nil || window < 1 {
+			return nil, fmt.Errorf("invalid transform, %s needs a window of at least 1 point: %s", name, spec)
+		}
+		switch name {
+		case TransformSMA:
+			return func(data []int) ([]int, error) { return SMA(data, window) }, nil
+		case TransformEMA:
+			return func(data []int) ([]int, error) { return EMA(data, window) }, nil
+		}
+		return func(data []int) ([]int, error) { return MedianFilter(data, window) }, nil
+	case TransformRate:
+		if hasParam {
+			var err error
+			if interval, err = ParseDuration(param); err != nil || interval <= 0 {
+				return nil, fmt.Errorf("invalid transform, rate needs a positive interval: %s", spec)
+			}
+		}
+		return func(data []int) ([]int, error) { return Rate(data, interval) }, nil
+	}
+
+	transforms := map[string]Transform{
+		TransformCumSum:    CumSum,
+		TransformDiff:      Diff,
+		TransformPctChange: PercentChange,
+		TransformNormalize: Normalize,
+	}
+	if transform, ok := transforms[name]; ok && !hasParam {
+		return transform, nil
+	}
+	return nil, fmt.Errorf("invalid transform: %s", spec)
+}
+
+// ApplyTransforms runs data through the transforms given as for ParseTransform, in order
+func ApplyTransforms(data []int, specs []string) ([]int, error) {
+	return applyTransforms(data, specs, time.Second)
+}
+
+func applyTransforms(data []int, specs []string, interval time.Duration) ([]int, error) {
+	for _, spec := range specs {
+		transform, err := parseTransform(spec, interval)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = transform(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", spec, err)
+		}
+	}
+	return data, nil
+}
+
+// transform applies config.Transforms to data, with rates sampled every transformInterval
+func (c *Config) transform(data []int) ([]int, error) {
+	if len(c.Transforms) == 0 {
+		return data, nil
+	}
+	return applyTransforms(data, c.Transforms, c.transformInterval())
+}
+
+// transformGaps applies config.Transforms to the points of data not marked in gaps, and returns
+// the transformed points with the gaps of the result. A point computed from several points, as
+// by diff or rate, is only kept when they follow each other without a gap between them.
+func (c *Config) transformGaps(data []int, gaps []bool) ([]int, []bool, error) {
+	var values, positions []int
+	for i, n := range data {
+		if !gaps[i] {
+			values = append(values, n)
+			positions = append(positions, i)
+		}
+	}
+
+	for _, spec := range c.Transforms {
+		transform, err := parseTransform(spec, c.transformInterval())
+		if err != nil {
+			return nil, nil, err
+		}
+		result, err := transform(values)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", spec, err)
+		}
+
+		// point j of a result span points shorter is computed from the points j to j+span
+		span := len(values) - len(result)
+		var kept, keptPositions []int
+		for j, n := range result {
+			if positions[j+span]-positions[j] == span {
+				kept = append(kept, n)
+				keptPositions = append(keptPositions, positions[j+span])
+			}
+		}
+		values, positions = kept, keptPositions
+	}
+
+	missing := slices.Repeat([]bool{true}, len(data))
+	for _, i := range positions {
+		missing[i] = false
+	}
+	return values, missing, nil
+}
+
+// transformInterval is the interval between two points of rate, the bucket when bucketing, or else
+// config.Interval, or else 1s
+func (c *Config) transformInterval() time.Duration {
+	if c.Bucket > 0 {
+		return c.Bucket
+	}
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	return time.Second
+}
+
+func validateTransforms(config *Config) error {
+	for _, spec := range config.Transforms {
+		if _, err := parseTransform(spec, time.Second); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SMA is the simple moving average of the last window points, or of all of them for the first ones
+func SMA(data []int, window int) ([]int, error) {
+	if window < 1 {
+		return nil, fmt.Errorf("window must be at least 1: %d", window)
+	}
+
+	result := make([]int, len(data))
+	sum := 0.0
+	for i, n := range data {
+		sum += float64(n)
+		if i >= window {
+			sum -= float64(data[i-window])
+		}
+
+		var err error
+		if result[i], err = roundPoint(data, i, sum/float64(min(i+1, window))); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// EMA is the exponential moving average spanning window points, with a smoothing factor of
+// 2 / (window + 1), starting from the first point
+func EMA(data []int, window int) ([]int, error) {
+	if window < 1 {
+		return nil, fmt.Errorf("window must be at least 1: %d", window)
+	}
+
+	alpha := 2 / float64(window+1)
+	result := make([]int, len(data))
+	average := 0.0
+	for i, n := range data {
+		if i == 0 {
+			average = float64(n)
+		} else {
+			average += alpha * (float64(n) - average)
+		}
+
+		var err error
+		if result[i], err = roundPoint(data, i, average); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// MedianFilter is the median of the last window points, which smooths out spikes shorter than
+// half the window
+func MedianFilter(data []int, window int) ([]int, error) {
+	if window < 1 {
+		return nil, fmt.Errorf("window must be at least 1: %d", window)
+	}
+
+	result := make([]int, len(data))
+	for i := range data {
+		sorted := slices.Clone(data[max(0, i-window+1) : i+1])
+		slices.Sort(sorted)
+
+		middle := len(sorted) / 2
+		median := float64(sorted[middle])
+		if len(sorted)%2 == 0 {
+			median = (float64(sorted[middle-1]) + median) / 2
+		}
+
+		var err error
+		if result[i], err = roundPoint(data, i, median); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// CumSum is the running total of data
+func CumSum(data []int) ([]int, error) {
+	result := make([]int, len(data))
+	sum := 0
+	for i, n := range data {
+		if n > 0 && sum > math.MaxInt-n {
+			return nil, &StatsError{Index: i, Value: n, Err: ErrOverflow}
+		}
+		if n < 0 && sum < math.MinInt-n {
+			return nil, &StatsError{Index: i, Value: n, Err: ErrUnderflow}
+		}
+		sum += n
+		result[i] = sum
+	}
+	return result, nil
+}
+
+// Diff is the difference between every point and the previous one, so it has one point less
+func Diff(data []int) ([]int, error) {
+	if len(data) < 2 {
+		return []int{}, nil
+	}
+
+	result := make([]int, len(data)-1)
+	for i := 1; i < len(data); i++ {
+		previous, n := data[i-1], data[i]
+		if previous < 0 && n > math.MaxInt+previous {
+			return nil, &StatsError{Index: i, Value: n, Err: ErrOverflow}
+		}
+		if previous > 0 && n < math.MinInt+previous {
+			return nil, &StatsError{Index: i, Value: n, Err: ErrUnderflow}
+		}
+		result[i-1] = n - previous
+	}
+	return result, nil
+}
+
+// Rate is the per-second increase of a counter sampled every interval, so it has one point less.
+// A decrease is taken as a counter reset, after which the counter increased from 0.
+func Rate(data []int, interval time.Duration) ([]int, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive: %s", interval)
+	}
+	if len(data) < 2 {
+		return []int{}, nil
+	}
+
+	result := make([]int, len(data)-1)
+	for i := 1; i < len(data); i++ {
+		increase := float64(data[i]) - float64(data[i-1])
+		if increase < 0 {
+			increase = float64(data[i])
+		}
+
+		var err error
+		if result[i-1], err = roundPoint(data, i, increase/interval.Seconds()); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// PercentChange is the change of every point from the previous one in percent, so it has one point
+// less. A change from 0 has no percentage and is an error.
+func PercentChange(data []int) ([]int, error) {
+	if len(data) < 2 {
+		return []int{}, nil
+	}
+
+	result := make([]int, len(data)-1)
+	for i := 1; i < len(data); i++ {
+		previous := float64(data[i-1])
+		if previous == 0 {
+			return nil, &StatsError{Index: i, Value: data[i], Err: ErrZeroChange}
+		}
+
+		var err error
+		if result[i-1], err = roundPoint(data, i, (float64(data[i])-previous)/math.Abs(previous)*100); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Normalize scales data linearly from 0 for its minimum to 100 for its maximum, or 0 for every
+// point when they are all the same
+func Normalize(data []int) ([]int, error) {
+	if len(data) == 0 {
+		return []int{}, nil
+	}
+
+	low, high := float64(slices.Min(data)), float64(slices.Max(data))
+	result := make([]int, len(data))
+	if low == high {
+		return result, nil
+	}
+
+	for i, n := range data {
+		result[i] = int(math.Round((float64(n) - low) / (high - low) * 100))
+	}
+	return result, nil
+}
+
+// roundPoint rounds the transformed value f of point i of data to the nearest integer
+func roundPoint(data []int, i int, f float64) (int, error) {
+	n, err := floatToInt(math.Round(f))
+	if err != nil {
+		return 0, &StatsError{Index: i, Value: data[i], Err: err}
+	}
+	return n, nil
+}
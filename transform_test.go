@@ -0,0 +1,125 @@
+package spark
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		name        string
+		specs       []string
+		data        []int
+		expected    []int
+		expectError bool
+		errorMsg    string
+	}{
+		{"sma", []string{"sma:3"}, []int{3, 6, 9, 3, 3}, []int{3, 5, 6, 6, 5}, false, ""},
+		{"sma wider than data", []string{"sma:10"}, []int{2, 4}, []int{2, 3}, false, ""},
+		{"ema", []string{"ema:3"}, []int{10, 20, 20, 0}, []int{10, 15, 18, 9}, false, ""},
+		{"median", []string{"median:3"}, []int{1, 9, 2, 3, 100, 4}, []int{1, 5, 2, 3, 3, 4}, false, ""},
+		{"cumsum", []string{"cumsum"}, []int{1, -2, 3}, []int{1, -1, 2}, false, ""},
+		{"diff", []string{"diff"}, []int{1, 3, 6, 4}, []int{2, 3, -2}, false, ""},
+		{"diff of a single point", []string{"diff"}, []int{5}, []int{}, false, ""},
+		{"rate", []string{"rate"}, []int{0, 10, 30}, []int{10, 20}, false, ""},
+		{"rate per interval", []string{"rate:10s"}, []int{0, 100, 300}, []int{10, 20}, false, ""},
+		{"rate after a counter reset", []string{"rate"}, []int{100, 150, 20}, []int{50, 20}, false, ""},
+		{"pctchange", []string{"pctchange"}, []int{50, 100, 25, -25}, []int{100, -75, -200}, false, ""},
+		{"normalize", []string{"normalize"}, []int{10, 15, 20, 30}, []int{0, 25, 50, 100}, false, ""},
+		{"normalize flat data", []string{"normalize"}, []int{7, 7}, []int{0, 0}, false, ""},
+		{"pipeline", []string{"cumsum", "diff"}, []int{4, 8, 1}, []int{8, 1}, false, ""},
+		{"no transforms", nil, []int{1, 2}, []int{1, 2}, false, ""},
+		{"unknown transform", []string{"smooth"}, []int{1}, nil, true, "invalid transform: smooth"},
+		{"unexpected parameter", []string{"diff:2"}, []int{1}, nil, true, "invalid transform: diff:2"},
+		{"missing window", []string{"sma"}, []int{1}, nil, true, "invalid transform, sma needs a window of at least 1 point: sma"},
+		{"zero window", []string{"median:0"}, []int{1}, nil, true, "invalid transform, median needs a window of at least 1 point: median:0"},
+		{"invalid interval", []string{"rate:soon"}, []int{1}, nil, true, "invalid transform, rate needs a positive interval: rate:soon"},
+		{"percent change from 0", []string{"pctchange"}, []int{1, 0, 5}, nil, true, "pctchange: point 3 (5): percent change from 0"},
+		{"cumsum overflow", []string{"cumsum"}, []int{1, 9223372036854775807}, nil, true, "cumsum: point 2 (9223372036854775807): numbers are too large, sum would overflow"},
+		{"diff underflow", []string{"diff"}, []int{1, -9223372036854775808}, nil, true, "diff: point 2 (-9223372036854775808): numbers are too large, sum would underflow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ApplyTransforms(tt.data, tt.specs)
+			if tt.expectError {
+				if err == nil || err.Error() != tt.errorMsg {
+					t.Errorf("expected error '%s', got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(actual, tt.expected) {
+				t.Errorf("got %v, want %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTransformWindows(t *testing.T) {
+	for name, transform := range map[string]func([]int, int) ([]int, error){"SMA": SMA, "EMA": EMA, "MedianFilter": MedianFilter} {
+		if _, err := transform([]int{1}, 0); err == nil || err.Error() != "window must be at least 1: 0" {
+			t.Errorf("%s: expected window error, got %v", name, err)
+		}
+	}
+	if _, err := Rate([]int{1, 2}, 0); err == nil || err.Error() != "interval must be positive: 0s" {
+		t.Errorf("Rate: expected interval error, got %v", err)
+	}
+}
+
+func TestSparkTransforms(t *testing.T) {
+	config := &Config{Transforms: []string{"diff"}, ShowStats: true}
+	actual, err := Spark([]int{1, 3, 6, 10, 15}, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "▁▃▅█ (min:2 max:5 avg:3.50)"; actual != expected {
+		t.Errorf("got '%s', want '%s'", actual, expected)
+	}
+
+	if _, err = Spark([]int{5}, config); err != ErrNoValuesLeft {
+		t.Errorf("got error %v, want %v", err, ErrNoValuesLeft)
+	}
+
+	// rates are per second of the known interval between points
+	config = &Config{Transforms: []string{"rate"}, Interval: 2 * time.Second, ShowStats: true}
+	actual, err = Spark([]int{0, 10, 30}, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "▁█ (min:5 max:10 avg:7.50)"; actual != expected {
+		t.Errorf("got '%s', want '%s'", actual, expected)
+	}
+
+	// the first bucket and the one after the gap have no rate from the bucket before, so they are
+	// blank like the empty one
+	config = &Config{Transforms: []string{"rate"}, Bucket: time.Minute, ShowStats: true}
+	actual, err = SparkGaps([]int{0, 60, 0, 300, 420}, []bool{false, false, true, false, false}, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := " ▁  █ (min:1 max:2 avg:1.50)"; actual != expected {
+		t.Errorf("got '%s', want '%s'", actual, expected)
+	}
+
+	// smoothing keeps its points, and a diff of them is only blank after the gap
+	config = &Config{Transforms: []string{"sma:1", "diff"}}
+	actual, err = SparkGaps([]int{10, 20, 0, 45, 50}, []bool{false, false, true, false, false}, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := " █  ▁"; actual != expected {
+		t.Errorf("got '%s', want '%s'", actual, expected)
+	}
+
+	series := []Series{{Label: "a", Data: []int{1, 2, 4}}, {Label: "b", Data: []int{0, 5}}}
+	if _, err = SparkSeries(series, &Config{Transforms: []string{"pctchange"}}); err == nil || err.Error() != "b: pctchange: point 2 (5): percent change from 0" {
+		t.Errorf("expected percent change error, got %v", err)
+	}
+	if !slices.Equal(series[0].Data, []int{1, 2, 4}) {
+		t.Errorf("transforms changed the series: %v", series[0].Data)
+	}
+}
@@ -0,0 +1,92 @@
+package spark
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	TrendRising  = "↗"
+	TrendFalling = "↘"
+	TrendFlat    = "→"
+)
+
+// trendScore is the number of standard errors a slope must be away from 0 to be a trend rather
+// than noise, about 95% confidence for more than a few points
+const trendScore = 2
+
+// Trend is the least-squares line through the points of a graph, numbered from 0, with the
+// standard error of its slope
+type Trend struct {
+	Slope     float64
+	Intercept float64
+	StdErr    float64
+}
+
+// LinearTrend fits a least-squares line through data, one point per step
+func LinearTrend(data []int) (Trend, error) {
+	positions := make([]int, len(data))
+	for i := range positions {
+		positions[i] = i
+	}
+	return fitTrend(positions, data)
+}
+
+// fitTrend fits a least-squares line through the points at positions, which may skip some steps
+func fitTrend(positions, data []int) (Trend, error) {
+	if len(data) == 0 {
+		return Trend{}, ErrNoData
+	}
+	if len(positions) != len(data) {
+		return Trend{}, fmt.Errorf("got %d positions for %d points", len(positions), len(data))
+	}
+
+	n := float64(len(data))
+	meanX, meanY := 0.0, 0.0
+	for i, y := range data {
+		meanX += float64(positions[i]) / n
+		meanY += float64(y) / n
+	}
+
+	sxx, sxy := 0.0, 0.0
+	for i, y := range data {
+		dx := float64(positions[i]) - meanX
+		sxx += dx * dx
+		sxy += dx * (float64(y) - meanY)
+	}
+	if sxx == 0 {
+		return Trend{Intercept: meanY}, nil
+	}
+
+	trend := Trend{Slope: sxy / sxx}
+	trend.Intercept = meanY - trend.Slope*meanX
+
+	// with two points the line goes through both, so there is no noise to compare the slope to
+	if len(data) > 2 {
+		residuals := 0.0
+		for i, y := range data {
+			r := float64(y) - (trend.Intercept + trend.Slope*float64(positions[i]))
+			residuals += r * r
+		}
+		trend.StdErr = math.Sqrt(residuals/(n-2)) / math.Sqrt(sxx)
+	}
+
+	return trend, nil
+}
+
+// Direction is TrendRising or TrendFalling when the slope is further from 0 than the noise of the
+// points around the line can explain, and TrendFlat otherwise
+func (t Trend) Direction() string {
+	if t.Slope == 0 || math.Abs(t.Slope) <= trendScore*t.StdErr {
+		return TrendFlat
+	}
+	if t.Slope > 0 {
+		return TrendRising
+	}
+	return TrendFalling
+}
+
+// String formats the direction and the slope per point, as in ↗+1.50
+func (t Trend) String() string {
+	return fmt.Sprintf("%s%+.2f", t.Direction(), t.Slope)
+}
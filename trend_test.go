@@ -0,0 +1,83 @@
+package spark
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLinearTrend(t *testing.T) {
+	tests := []struct {
+		name              string
+		data              []int
+		expectedSlope     float64
+		expectedIntercept float64
+		expectedDirection string
+	}{
+		{"rising line", []int{1, 2, 3, 4, 5}, 1, 1, TrendRising},
+		{"falling line", []int{10, 8, 6, 4}, -2, 10, TrendFalling},
+		{"falling beyond the noise", []int{10, 9, 7, 8, 5, 3}, -1.31, 10.29, TrendFalling},
+		{"noise", []int{5, 1, 6, 2, 5, 1, 6}, 0.07, 3.5, TrendFlat},
+		{"flat", []int{3, 3, 3}, 0, 3, TrendFlat},
+		{"single point", []int{4}, 0, 4, TrendFlat},
+		{"two points", []int{2, 1}, -1, 2, TrendFalling},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend, err := LinearTrend(tt.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(trend.Slope-tt.expectedSlope) > 0.01 {
+				t.Errorf("got slope %f, want %f", trend.Slope, tt.expectedSlope)
+			}
+			if math.Abs(trend.Intercept-tt.expectedIntercept) > 0.01 {
+				t.Errorf("got intercept %f, want %f", trend.Intercept, tt.expectedIntercept)
+			}
+			if direction := trend.Direction(); direction != tt.expectedDirection {
+				t.Errorf("got direction %s, want %s", direction, tt.expectedDirection)
+			}
+		})
+	}
+
+	if _, err := LinearTrend(nil); err != ErrNoData {
+		t.Errorf("got error %v, want %v", err, ErrNoData)
+	}
+}
+
+func TestSparkTrend(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		data     []int
+		expected string
+	}{
+		{"trend only", Config{ShowTrend: true}, []int{1, 2, 3, 4, 5}, "▁▂▄▆█ (trend:↗+1.00)"},
+		{"after stats", Config{ShowTrend: true, ShowStats: true}, []int{5, 1, 6, 2, 5, 1, 6}, "▆▁█▂▆▁█ (min:1 max:6 avg:3.71 trend:→+0.07)"},
+		{"before breaches", Config{ShowTrend: true, ShowBreaches: true, Warn: intPtr(8)}, []int{10, 8, 6, 4}, "█▅▃▁ (trend:↘-2.00 warn:2)"},
+		{"in data order when reversed", Config{ShowTrend: true, Reverse: true}, []int{1, 2, 3}, "█▄▁ (trend:↗+1.00)"},
+		{"of the values of a histogram", Config{ShowTrend: true, Histogram: true, Bins: 2}, []int{1, 2, 3, 4}, "▅▅ (trend:↗+1.00)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ColorMode = ColorNever
+			actual, err := Spark(tt.data, &tt.config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tt.expected {
+				t.Errorf("got '%s', want '%s'", actual, tt.expected)
+			}
+		})
+	}
+
+	// points after a gap are further along the line
+	actual, err := SparkGaps([]int{5, 0, 0, 0, 9, 10}, []bool{false, true, true, true, false, false}, &Config{ShowTrend: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "▁   ▆█ (trend:↗+1.00)"; actual != expected {
+		t.Errorf("got '%s', want '%s'", actual, expected)
+	}
+}
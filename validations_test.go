@@ -378,3 +378,315 @@ func TestValidateColor(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateGradient(t *testing.T) {
+	tests := []struct {
+		name        string
+		stops       []string
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "no gradient should be valid",
+			stops:       nil,
+			expectError: false,
+		},
+		{
+			name:        "two colors should be valid",
+			stops:       []string{"green", "red"},
+			expectError: false,
+		},
+		{
+			name:        "three colors should be valid",
+			stops:       []string{"green", "yellow", "red"},
+			expectError: false,
+		},
+		{
+			name:        "single color should be invalid",
+			stops:       []string{"green"},
+			expectError: true,
+			errorMsg:    "gradient needs at least two colors: green",
+		},
+		{
+			name:        "invalid stop should be invalid",
+			stops:       []string{"green", "purple"},
+			expectError: true,
+			errorMsg:    "invalid color: purple",
+		},
+		{
+			name:        "empty stop should be invalid",
+			stops:       []string{"green", ""},
+			expectError: true,
+			errorMsg:    "invalid color: gradient stops cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGradient(tt.stops)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if tt.errorMsg != "" && err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateThresholds(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:   "no thresholds should be valid",
+			config: Config{},
+		},
+		{
+			name:   "warn below crit should be valid",
+			config: Config{Warn: intPtr(70), Crit: intPtr(90)},
+		},
+		{
+			name:   "warn above crit in below mode should be valid",
+			config: Config{Warn: intPtr(20), Crit: intPtr(10), ThresholdMode: "below"},
+		},
+		{
+			name:        "warn above crit should be invalid",
+			config:      Config{Warn: intPtr(90), Crit: intPtr(70)},
+			expectError: true,
+			errorMsg:    "warning threshold 90 must not be above critical threshold 70",
+		},
+		{
+			name:        "warn below crit in below mode should be invalid",
+			config:      Config{Warn: intPtr(10), Crit: intPtr(20), ThresholdMode: "below"},
+			expectError: true,
+			errorMsg:    "warning threshold 10 must not be below critical threshold 20",
+		},
+		{
+			name:        "unknown mode should be invalid",
+			config:      Config{ThresholdMode: "sideways"},
+			expectError: true,
+			errorMsg:    "invalid threshold mode: sideways",
+		},
+		{
+			name:        "invalid warn color should be invalid",
+			config:      Config{WarnColor: "orange"},
+			expectError: true,
+			errorMsg:    "invalid color: orange",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if tt.errorMsg != "" && err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateStyle(t *testing.T) {
+	tests := []struct {
+		name        string
+		style       []string
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:  "no style should be valid",
+			style: nil,
+		},
+		{
+			name:  "color only should be valid",
+			style: []string{"red"},
+		},
+		{
+			name:  "attributes only should be valid",
+			style: []string{"bold", "underline"},
+		},
+		{
+			name:  "color and attribute should be valid",
+			style: []string{"bold", "red"},
+		},
+		{
+			name:        "unknown name should be invalid",
+			style:       []string{"red", "sparkly"},
+			expectError: true,
+			errorMsg:    "invalid style: sparkly",
+		},
+		{
+			name:        "two colors should be invalid",
+			style:       []string{"red", "blue"},
+			expectError: true,
+			errorMsg:    "invalid style: more than one color in red,blue",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStyle(tt.style)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+					return
+				}
+				if tt.errorMsg != "" && err.Error() != tt.errorMsg {
+					t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestShouldColor(t *testing.T) {
+	tests := []struct {
+		name       string
+		noColor    string
+		forceColor string
+		expected   bool
+	}{
+		{
+			name:     "file output should not be colored",
+			expected: false,
+		},
+		{
+			name:       "force color should color file output",
+			forceColor: "1",
+			expected:   true,
+		},
+		{
+			name:       "force color set to zero should not color",
+			forceColor: "0",
+			expected:   false,
+		},
+		{
+			name:       "no color should win over force color",
+			noColor:    "1",
+			forceColor: "1",
+			expected:   false,
+		},
+	}
+
+	out, err := os.CreateTemp("", "test_stdout")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer func() {
+		_ = out.Close()
+		_ = os.Remove(out.Name())
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("FORCE_COLOR", tt.forceColor)
+
+			if actual := ShouldColor(out); actual != tt.expected {
+				t.Errorf("got %v, want %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveColorMode(t *testing.T) {
+	out, err := os.CreateTemp("", "test_stdout")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer func() {
+		_ = out.Close()
+		_ = os.Remove(out.Name())
+	}()
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	if mode := ResolveColorMode(ColorAuto, out); mode != ColorNever {
+		t.Errorf("got %s for a file, want %s", mode, ColorNever)
+	}
+	if mode := ResolveColorMode(ColorAlways, out); mode != ColorAlways {
+		t.Errorf("got %s for always, want %s", mode, ColorAlways)
+	}
+
+	t.Setenv("FORCE_COLOR", "1")
+	if mode := ResolveColorMode(ColorAuto, out); mode != ColorAlways {
+		t.Errorf("got %s with FORCE_COLOR, want %s", mode, ColorAlways)
+	}
+	if mode := ResolveColorMode(ColorNever, out); mode != ColorNever {
+		t.Errorf("got %s for never, want %s", mode, ColorNever)
+	}
+}
+
+func TestValidateColorMode(t *testing.T) {
+	for _, mode := range []string{"", "auto", "always", "never"} {
+		if err := ValidateColorMode(mode); err != nil {
+			t.Errorf("unexpected error for '%s': %v", mode, err)
+		}
+	}
+
+	err := ValidateColorMode("sometimes")
+	if err == nil || err.Error() != "invalid color mode: sometimes" {
+		t.Errorf("expected invalid color mode error, got %v", err)
+	}
+}
+
+func TestValidateGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		errorMsg string
+	}{
+		{"negative group-by", Config{GroupBy: -1}, "invalid group-by column: -1"},
+		{"negative value column", Config{GroupBy: 1, ValueColumn: -2}, "invalid value column: -2"},
+		{"value without group-by", Config{ValueColumn: 2}, "value column needs a group-by column or time buckets"},
+		{"same columns", Config{GroupBy: 2, ValueColumn: 2}, "group-by and value columns must be different: 2"},
+		{"group-by with series mode", Config{GroupBy: 1, SeriesMode: "line"}, "group-by cannot be combined with series mode line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if err == nil {
+				t.Errorf("expected error but got none")
+				return
+			}
+			if err.Error() != tt.errorMsg {
+				t.Errorf("expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+
+	if err := (&Config{GroupBy: 1, ValueColumn: 3}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
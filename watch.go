@@ -0,0 +1,95 @@
+package spark
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Watch runs command every interval, appends the numbers it prints to a rolling window of size
+// values and redraws the sparkline in place on w, until ctx is done. Runs that fail or print no
+// numbers are reported on errs and the next ones go on. It returns how many invalid tokens were
+// skipped over all the runs when config.SkipInvalid is set.
+func Watch(ctx context.Context, command []string, interval time.Duration, w, errs io.Writer, size int, config *Config) (int, error) {
+	if interval <= 0 {
+		return 0, fmt.Errorf("interval must be positive: %s", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	return watch(ctx, command, ticker.C, w, errs, size, config)
+}
+
+// watch runs command once, then again on every tick until ctx is done or ticks is closed
+func watch(ctx context.Context, command []string, ticks <-chan time.Time, w, errs io.Writer, size int, config *Config) (int, error) {
+	if len(command) == 0 {
+		return 0, fmt.Errorf("no command to watch")
+	}
+	if config.Vertical {
+		return 0, fmt.Errorf("vertical graphs cannot be redrawn in place")
+	}
+
+	window, err := NewWindow(size)
+	if err != nil {
+		return 0, err
+	}
+
+	skipped := 0
+	drawn := false
+	for {
+		output, err := exec.CommandContext(ctx, command[0], command[1:]...).Output()
+		if ctx.Err() != nil {
+			return skipped, finishRedraw(w, drawn, nil)
+		}
+
+		var values []int
+		var exitErr *exec.ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			err = fmt.Errorf("command failed: %w", err)
+		case err != nil:
+			return skipped, finishRedraw(w, drawn, fmt.Errorf("command failed: %w", err))
+		default:
+			var n int
+			values, n, err = parseReader(bytes.NewReader(output), config)
+			skipped += n
+			if errors.Is(err, ErrNoData) {
+				err = fmt.Errorf("command printed no numeric data: %s", strings.Join(command, " "))
+			} else if err != nil {
+				return skipped, finishRedraw(w, drawn, setSource(err, "<"+command[0]+">"))
+			}
+		}
+
+		if err != nil {
+			// a failed run is reported on a line of its own, and the graph goes on below it
+			if printErr := finishRedraw(w, drawn, nil); printErr != nil {
+				return skipped, printErr
+			}
+			if _, printErr := fmt.Fprintln(errs, err); printErr != nil {
+				return skipped, printErr
+			}
+			drawn = false
+		} else {
+			window.Push(values...)
+			if err = redraw(w, window.Values(), config); err != nil {
+				return skipped, finishRedraw(w, drawn, err)
+			}
+			drawn = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return skipped, finishRedraw(w, drawn, nil)
+		case _, ok := <-ticks:
+			if !ok {
+				return skipped, finishRedraw(w, drawn, nil)
+			}
+		}
+	}
+}
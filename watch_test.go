@@ -0,0 +1,113 @@
+package spark
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runWatch runs the command as many times as runs, one tick after the other
+func runWatch(t *testing.T, command []string, runs int, config *Config) (string, string, int) {
+	t.Helper()
+
+	ticks := make(chan time.Time)
+	go func() {
+		for range runs - 1 {
+			ticks <- time.Time{}
+		}
+		close(ticks)
+	}()
+
+	var out, errs bytes.Buffer
+	skipped, err := watch(context.Background(), command, ticks, &out, &errs, 3, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return out.String(), errs.String(), skipped
+}
+
+func TestWatch(t *testing.T) {
+	out, _, _ := runWatch(t, []string{"echo", "5"}, 4, &Config{ShowSum: true})
+
+	redraws := strings.Split(strings.TrimSuffix(out, "\n"), "\r")[1:]
+	want := []string{"▅ (sum:5)\033[K", "▅▅ (sum:10)\033[K", "▅▅▅ (sum:15)\033[K", "▅▅▅ (sum:15)\033[K"}
+	// the window never holds more than 3 values
+	if strings.Join(redraws, "|") != strings.Join(want, "|") {
+		t.Errorf("got redraws %q, want %q", redraws, want)
+	}
+	if !strings.HasSuffix(out, "\n") {
+		t.Errorf("expected output to end with a newline, got %q", out)
+	}
+}
+
+func TestWatchCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out, errs bytes.Buffer
+	if _, err := watch(ctx, []string{"echo", "5"}, make(chan time.Time), &out, &errs, 3, &Config{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "" || errs.String() != "" {
+		t.Errorf("expected no output, got %q and %q", out.String(), errs.String())
+	}
+}
+
+func TestWatchFailingRuns(t *testing.T) {
+	// the second run fails and the third one prints nothing, then the counter goes on
+	counter := filepath.Join(t.TempDir(), "counter")
+	script := `n=$(($(cat "$0" 2>/dev/null || echo 0) + 1)); echo $n > "$0"
+[ $n -eq 2 ] && exit 1; [ $n -eq 3 ] && exit 0; echo $n`
+
+	out, errs, _ := runWatch(t, []string{"sh", "-c", script, counter}, 5, &Config{ShowSum: true})
+
+	wantOut := "\r▅ (sum:1)\033[K\n\r▁█ (sum:5)\033[K\r▁▆█ (sum:10)\033[K\n"
+	if out != wantOut {
+		t.Errorf("got output %q, want %q", out, wantOut)
+	}
+	wantErrs := "command failed: exit status 1\ncommand printed no numeric data: sh -c " + script + " " + counter + "\n"
+	if errs != wantErrs {
+		t.Errorf("got errors %q, want %q", errs, wantErrs)
+	}
+}
+
+func TestWatchSkipped(t *testing.T) {
+	_, _, skipped := runWatch(t, []string{"echo", "5 abc x"}, 2, &Config{SkipInvalid: true})
+	if skipped != 4 {
+		t.Errorf("got %d skipped tokens, want 4", skipped)
+	}
+}
+
+func TestWatchErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  []string
+		interval time.Duration
+		config   Config
+		errorMsg string
+	}{
+		{"no command", nil, time.Second, Config{}, "no command to watch"},
+		{"invalid interval", []string{"echo", "1"}, 0, Config{}, "interval must be positive: 0s"},
+		{"missing command", []string{"gospark-no-such-command"}, time.Second, Config{}, "command failed: exec"},
+		{"invalid output", []string{"echo", "abc"}, time.Second, Config{}, "invalid number: abc"},
+		{"vertical", []string{"echo", "1"}, time.Second, Config{Vertical: true}, "vertical graphs cannot be redrawn in place"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errs bytes.Buffer
+			_, err := Watch(context.Background(), tt.command, tt.interval, &out, &errs, 10, &tt.config)
+
+			if err == nil {
+				t.Errorf("expected error but got none")
+				return
+			}
+			if !contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error message to contain '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}